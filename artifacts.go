@@ -0,0 +1,58 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package go_smilo_regression
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/onsi/ginkgo"
+
+	"go-smilo/src/blockchain/regression/src/container"
+)
+
+// CollectArtifacts saves the artifacts of the collectors into a directory of
+// the current spec if it failed. Call it from AfterEach before stopping the
+// blockchain, which removes the containers and their logs.
+func CollectArtifacts(collectors ...container.ArtifactCollector) {
+	desc := ginkgo.CurrentGinkgoTestDescription()
+	if !desc.Failed {
+		return
+	}
+
+	dir := container.ArtifactsDir(desc.FullTestText)
+	for _, c := range collectors {
+		// Skip the blockchains and vaults a failed BeforeEach did not create
+		if c == nil || reflect.ValueOf(c).IsNil() {
+			continue
+		}
+		if err := c.CollectArtifacts(dir); err != nil {
+			fmt.Fprintln(ginkgo.GinkgoWriter, err)
+		}
+	}
+
+	if os.Getenv(container.ArtifactsArchiveEnv) != "" {
+		archive, err := container.ArchiveArtifacts(dir)
+		if err != nil {
+			fmt.Fprintln(ginkgo.GinkgoWriter, "Failed to archive artifacts", err)
+		} else {
+			dir = archive
+		}
+	}
+	fmt.Fprintf(ginkgo.GinkgoWriter, "Artifacts of the failed spec saved to %s\n", dir)
+}
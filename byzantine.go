@@ -0,0 +1,45 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package go_smilo_regression
+
+import (
+	"fmt"
+
+	"go-smilo/src/blockchain/regression/src/container"
+)
+
+// ByzantineCases returns a case for every faulty behaviour and number of
+// faulty fullnodes up to F, for blockchains of each of the given sizes. The
+// parameters of a case are the number of normal fullnodes and the behaviours
+// of the faulty fullnodes.
+func ByzantineCases(sizes ...int) []TableEntry {
+	var cases []TableEntry
+	for _, size := range sizes {
+		f := (size - 1) / 3
+		for _, behaviour := range container.FaultyBehaviours() {
+			for numberOfFaulty := 1; numberOfFaulty <= f; numberOfFaulty++ {
+				faulty := make([]container.FaultyBehaviour, numberOfFaulty)
+				for i := range faulty {
+					faulty[i] = behaviour
+				}
+				description := fmt.Sprintf("%d fullnodes, %d %s", size, numberOfFaulty, behaviour)
+				cases = append(cases, Case(description, size-numberOfFaulty, faulty))
+			}
+		}
+	}
+	return cases
+}
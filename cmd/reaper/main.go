@@ -0,0 +1,63 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// reaper removes the containers, networks and temp dirs leaked by regression
+// runs, e.g. after a suite panicked:
+//
+//	reaper -run 1577836800-4242
+//	reaper -older-than 6h
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"go-smilo/src/blockchain/regression/src/common"
+	"go-smilo/src/blockchain/regression/src/container"
+)
+
+func main() {
+	runID := flag.String("run", os.Getenv(common.RunIDEnv), "id of the run to reap, defaults to "+common.RunIDEnv)
+	olderThan := flag.Duration("older-than", 0, "reap every run older than the duration instead, e.g. 6h")
+	flag.Parse()
+
+	if *runID == "" && *olderThan == 0 {
+		fmt.Fprintln(os.Stderr, "either -run or -older-than is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	reaper, err := container.NewReaper()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to create reaper:", err)
+		os.Exit(1)
+	}
+
+	var report *container.ReapReport
+	if *olderThan != 0 {
+		report, err = reaper.ReapOlderThan(*olderThan)
+	} else {
+		report, err = reaper.ReapRun(*runID)
+	}
+	if report != nil {
+		fmt.Printf("Removed %d containers, %d networks and %d dirs\n", len(report.Containers), len(report.Networks), len(report.Dirs))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
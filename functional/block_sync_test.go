@@ -22,6 +22,7 @@ import (
 	"context"
 	"math/big"
 	"sync"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -44,13 +45,15 @@ var _ = Describe("Block synchronization testing", func() {
 	)
 
 	BeforeEach(func() {
-		blockchain, err := container.NewDefaultBlockchain(dockerNetwork, numberOfFullnodes)
+		var err error
+		blockchain, err = container.NewDefaultBlockchain(dockerNetwork, numberOfFullnodes)
 		Expect(err).To(BeNil())
 		Expect(blockchain).ToNot(BeNil())
-		Expect(blockchain.Start(true)).To(BeNil())
+		Expect(blockchain.Start(container.FullMesh())).To(BeNil())
 	})
 
 	AfterEach(func() {
+		tests.CollectArtifacts(blockchain)
 		Expect(blockchain.Stop(true)).To(BeNil())
 		blockchain.Finalize()
 	})
@@ -169,4 +172,49 @@ var _ = Describe("Block synchronization testing", func() {
 			close(done)
 		}, 50)
 	})
+
+	Describe("TFS-06: Observers", func() {
+		const numberOfObservers = 2
+
+		It("TFS-06-03: Observers follow the chain without becoming fullnodes", func(done Done) {
+			var observers []container.Ethereum
+
+			By("Add observers", func() {
+				var err error
+				observers, err = blockchain.AddObservers(numberOfObservers)
+				Expect(err).To(BeNil())
+				Expect(observers).To(HaveLen(numberOfObservers))
+				Expect(blockchain.Fullnodes()).To(HaveLen(numberOfFullnodes))
+			})
+
+			By("Wait for blocks", func() {
+				tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+					Expect(geth.WaitForBlocks(5)).To(BeNil())
+					wg.Done()
+				})
+			})
+
+			By("Observers should catch up to the fullnodes", func() {
+				Expect(blockchain.WaitForObserversCaughtUp(time.Minute)).To(BeNil())
+			})
+
+			By("Observers should not be fullnodes", func() {
+				client := blockchain.Fullnodes()[0].NewClient()
+				Expect(client).NotTo(BeNil())
+				defer client.Close()
+				fullnodes, err := client.GetFullnodes(context.Background(), nil)
+				Expect(err).To(BeNil())
+				Expect(fullnodes).To(HaveLen(numberOfFullnodes))
+				for _, o := range observers {
+					Expect(fullnodes).NotTo(ContainElement(o.Address()))
+				}
+			})
+
+			By("Observers and fullnodes should agree on every block", func() {
+				expectSafety(append(blockchain.Fullnodes(), observers...))
+			})
+
+			close(done)
+		}, 300)
+	})
 })
@@ -36,63 +36,68 @@ func TestBizantine(t *testing.T) {
 
 var _ = Describe("TFS-05: Byzantine Faulty", func() {
 
-	Context("TFS-05-01: F faulty fullnodes", func() {
-		const (
-			numberOfNormal = 3
-			numberOfFaulty = 1
-		)
-		var (
-			blockchain container.Blockchain
-		)
-		BeforeEach(func() {
-			blockchain, err := container.NewDefaultBlockchainWithFaulty(dockerNetwork, numberOfNormal, numberOfFaulty)
-			Expect(err).To(BeNil())
-			Expect(blockchain).ToNot(BeNil())
-			Expect(blockchain.Start(true)).To(BeNil())
-		})
+	tests.DescribeTable("TFS-05-01: F faulty fullnodes",
+		func(numberOfNormal int, faulty []container.FaultyBehaviour) {
+			var (
+				blockchain container.Blockchain
+				err        error
+			)
+			BeforeEach(func() {
+				blockchain, err = container.NewDefaultBlockchainWithFaulty(dockerNetwork, numberOfNormal, faulty)
+				Expect(err).To(BeNil())
+				Expect(blockchain).ToNot(BeNil())
+				Expect(blockchain.Start(container.FullMesh())).To(BeNil())
+			})
 
-		AfterEach(func() {
-			Expect(blockchain.Stop(true)).To(BeNil())
-			blockchain.Finalize()
-		})
+			AfterEach(func() {
+				tests.CollectArtifacts(blockchain)
+				Expect(blockchain.Stop(true)).To(BeNil())
+				blockchain.Finalize()
+			})
 
-		It("Should generate blocks", func(done Done) {
+			It("Should generate blocks", func(done Done) {
 
-			By("Wait for p2p connection", func() {
-				tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
-					Expect(geth.WaitForPeersConnected(numberOfNormal + numberOfFaulty - 1)).To(BeNil())
-					wg.Done()
+				By("Wait for p2p connection", func() {
+					tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+						Expect(geth.WaitForPeersConnected(numberOfNormal + len(faulty) - 1)).To(BeNil())
+						wg.Done()
+					})
 				})
-			})
 
-			By("Wait for blocks", func() {
-				const targetBlockHeight = 3
-				tests.WaitFor(blockchain.Fullnodes()[:1], func(geth container.Ethereum, wg *sync.WaitGroup) {
-					Expect(geth.WaitForBlocks(targetBlockHeight)).To(BeNil())
-					wg.Done()
+				By("Wait for blocks", func() {
+					const targetBlockHeight = 3
+					tests.WaitFor(blockchain.Fullnodes()[:1], func(geth container.Ethereum, wg *sync.WaitGroup) {
+						Expect(geth.WaitForBlocks(targetBlockHeight)).To(BeNil())
+						wg.Done()
+					})
 				})
-			})
 
-			close(done)
-		}, 50)
-	})
+				By("The normal fullnodes should agree on every block", func() {
+					expectSafety(blockchain.Fullnodes()[:numberOfNormal])
+				})
+
+				close(done)
+			}, 50)
+		},
+		tests.ByzantineCases(4, 7)...,
+	)
 
 	Context("TFS-05-01: F+1 faulty fullnodes", func() {
-		const (
-			numberOfNormal = 2
-			numberOfFaulty = 2
-		)
+		const numberOfNormal = 2
 		var (
+			faulty     = []container.FaultyBehaviour{container.RandomFault, container.RandomFault}
 			blockchain container.Blockchain
+			err        error
 		)
 		BeforeEach(func() {
-			blockchain, err := container.NewDefaultBlockchainWithFaulty(dockerNetwork, numberOfNormal, numberOfFaulty)
+			blockchain, err = container.NewDefaultBlockchainWithFaulty(dockerNetwork, numberOfNormal, faulty)
 			Expect(err).To(BeNil())
 			Expect(blockchain).ToNot(BeNil())
-			Expect(blockchain.Start(true)).To(BeNil())
+			Expect(blockchain.Start(container.FullMesh())).To(BeNil())
 		})
 
 		AfterEach(func() {
+			tests.CollectArtifacts(blockchain)
 			Expect(blockchain.Stop(true)).To(BeNil())
 			blockchain.Finalize()
 		})
@@ -100,18 +105,22 @@ var _ = Describe("TFS-05: Byzantine Faulty", func() {
 		It("Should not generate blocks", func(done Done) {
 			By("Wait for p2p connection", func() {
 				tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
-					Expect(geth.WaitForPeersConnected(numberOfNormal + numberOfFaulty - 1)).To(BeNil())
+					Expect(geth.WaitForPeersConnected(numberOfNormal + len(faulty) - 1)).To(BeNil())
 					wg.Done()
 				})
 			})
 
 			By("Wait for blocks", func() {
 				// Only check normal fullnodes
-				tests.WaitFor(blockchain.Fullnodes()[:2], func(geth container.Ethereum, wg *sync.WaitGroup) {
+				tests.WaitFor(blockchain.Fullnodes()[:numberOfNormal], func(geth container.Ethereum, wg *sync.WaitGroup) {
 					Expect(geth.WaitForNoBlocks(0, time.Second*30)).To(BeNil())
 					wg.Done()
 				})
 			})
+
+			By("The normal fullnodes should agree on every block", func() {
+				expectSafety(blockchain.Fullnodes()[:numberOfNormal])
+			})
 			close(done)
 		}, 60)
 	})
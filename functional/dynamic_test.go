@@ -48,10 +48,11 @@ var _ = Describe("TFS-02: Dynamic fullnodes addition/removal testing", func() {
 		blockchain, err := container.NewDefaultBlockchain(dockerNetwork, numberOfFullnodes)
 		Expect(err).To(BeNil())
 		Expect(blockchain).ToNot(BeNil())
-		Expect(blockchain.Start(true)).To(BeNil())
+		Expect(blockchain.Start(container.FullMesh())).To(BeNil())
 	})
 
 	AfterEach(func() {
+		tests.CollectArtifacts(blockchain)
 		Expect(blockchain.Stop(true)).To(BeNil())
 		blockchain.Finalize()
 	})
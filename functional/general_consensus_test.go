@@ -53,10 +53,11 @@ var _ = Describe("TFS-01: General consensus", func() {
 		blockchain, err := container.NewDefaultBlockchain(dockerNetwork, numberOfFullnodes)
 		Expect(err).To(BeNil())
 		Expect(blockchain).ToNot(BeNil())
-		Expect(blockchain.Start(true)).To(BeNil())
+		Expect(blockchain.Start(container.FullMesh())).To(BeNil())
 	})
 
 	AfterEach(func() {
+		tests.CollectArtifacts(blockchain)
 		blockchain.Stop(true) // This will return container not found error since we stop one
 		blockchain.Finalize()
 	})
@@ -46,10 +46,11 @@ var _ = Describe("TFS-07: Gossip Network", func() {
 		blockchain, err := container.NewDefaultBlockchain(dockerNetwork, numberOfFullnodes)
 		Expect(err).To(BeNil())
 		Expect(blockchain).ToNot(BeNil())
-		Expect(blockchain.Start(false)).To(BeNil())
+		Expect(blockchain.Start(container.Ring())).To(BeNil())
 	})
 
 	AfterEach(func() {
+		tests.CollectArtifacts(blockchain)
 		Expect(blockchain.Stop(true)).To(BeNil())
 		blockchain.Finalize()
 	})
@@ -0,0 +1,158 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package functional
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	tests "go-smilo/src/blockchain/regression"
+	"go-smilo/src/blockchain/regression/src/container"
+	"go-smilo/src/blockchain/regression/src/load"
+	"go-smilo/src/blockchain/regression/src/signer"
+)
+
+var _ = Describe("TFS-09: Sustained load", func() {
+	const (
+		numberOfFullnodes = 4
+		numberOfAccounts  = 20
+	)
+	var (
+		blockchain container.Blockchain
+		accounts   []*load.Account
+		err        error
+	)
+
+	BeforeEach(func() {
+		accounts, err = load.NewAccounts(numberOfAccounts)
+		Expect(err).To(BeNil())
+		balance, _ := new(big.Int).SetString("1000000000000000000000000", 10)
+		blockchain, err = container.NewDefaultBlockchain(dockerNetwork, numberOfFullnodes, load.Fund(accounts, balance))
+		Expect(err).To(BeNil())
+		Expect(blockchain).ToNot(BeNil())
+		Expect(blockchain.Start(container.FullMesh())).To(BeNil())
+
+		// EIP-155 signatures are valid from block 3 of the default genesis
+		tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+			Expect(geth.WaitForBlockHeight(3)).To(BeNil())
+			wg.Done()
+		})
+	})
+
+	AfterEach(func() {
+		tests.CollectArtifacts(blockchain)
+		blockchain.Stop(true)
+		blockchain.Finalize()
+	})
+
+	It("TFS-09-01: Every transaction should be confirmed", func(done Done) {
+		generator := load.New(blockchain.Fullnodes(), accounts,
+			load.TPS(20),
+			load.Duration(time.Minute),
+			load.Mix(load.Transfer, 8),
+			load.Mix(load.Deploy, 1),
+			load.Mix(load.Call, 1),
+		)
+		report, err := generator.Run(context.Background())
+		Expect(err).To(BeNil())
+		fmt.Fprint(GinkgoWriter, report)
+
+		total := report.Total()
+		Expect(report.Errors).To(BeEmpty())
+		Expect(total.Submitted).To(BeNumerically(">", 0))
+		Expect(total.Confirmed).To(Equal(total.Submitted))
+
+		By("All fullnodes should agree on every block", func() {
+			expectSafety(blockchain.Fullnodes())
+		})
+
+		close(done)
+	}, 600)
+
+	It("TFS-09-02: Send from the accounts of the fullnodes to any node", func(done Done) {
+		s, err := signer.ForBlockchain(blockchain)
+		Expect(err).To(BeNil())
+
+		generator := load.New(blockchain.Fullnodes(), nil,
+			load.Signer(s),
+			load.TPS(10),
+			load.Duration(20*time.Second),
+		)
+		report, err := generator.Run(context.Background())
+		Expect(err).To(BeNil())
+		fmt.Fprint(GinkgoWriter, report)
+
+		total := report.Total()
+		Expect(report.Errors).To(BeEmpty())
+		Expect(total.Confirmed).To(Equal(total.Submitted))
+
+		close(done)
+	}, 300)
+
+	It("TFS-09-03: A replayed nonce should be rejected", func(done Done) {
+		s, err := signer.ForBlockchain(blockchain)
+		Expect(err).To(BeNil())
+		fullnodes := blockchain.Fullnodes()
+		from := s.Accounts()[0]
+		to := s.Accounts()[1]
+
+		first := fullnodes[0].NewClient()
+		Expect(first).NotTo(BeNil())
+		defer first.Close()
+		other := fullnodes[1].NewClient()
+		Expect(other).NotTo(BeNil())
+		defer other.Close()
+
+		var nonce uint64
+		By("Send a transaction", func() {
+			tx, err := s.NewTransaction(context.Background(), first, from, &to, big.NewInt(1), 21000, nil)
+			Expect(err).To(BeNil())
+			Expect(s.Send(context.Background(), first, tx)).To(BeNil())
+			nonce = tx.Nonce()
+		})
+
+		By("Wait for the transaction to be in a block", func() {
+			tests.WaitFor(fullnodes, func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(2)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("Replay its nonce to another fullnode", func() {
+			Expect(s.SetNonce(from, nonce)).To(BeNil())
+			tx, err := s.NewTransaction(context.Background(), other, from, &to, big.NewInt(2), 21000, nil)
+			Expect(err).To(BeNil())
+			Expect(s.Send(context.Background(), other, tx)).NotTo(BeNil())
+		})
+
+		By("The next nonce is read from the node again", func() {
+			s.Resync(from)
+			tx, err := s.NewTransaction(context.Background(), other, from, &to, big.NewInt(3), 21000, nil)
+			Expect(err).To(BeNil())
+			Expect(tx.Nonce()).To(Equal(nonce + 1))
+			Expect(s.Send(context.Background(), other, tx)).To(BeNil())
+		})
+
+		close(done)
+	}, 120)
+})
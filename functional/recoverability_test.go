@@ -19,6 +19,7 @@
 package functional
 
 import (
+	"errors"
 	"sync"
 	"time"
 
@@ -40,16 +41,18 @@ var _ = Describe("TFS-03: Recoverability testing", func() {
 	)
 	var (
 		blockchain container.Blockchain
+		err        error
 	)
 
 	BeforeEach(func() {
-		blockchain, err := container.NewDefaultBlockchain(dockerNetwork, numberOfFullnodes)
+		blockchain, err = container.NewDefaultBlockchain(dockerNetwork, numberOfFullnodes)
 		Expect(err).To(BeNil())
 		Expect(blockchain).ToNot(BeNil())
-		Expect(blockchain.Start(true)).To(BeNil())
+		Expect(blockchain.Start(container.FullMesh())).To(BeNil())
 	})
 
 	AfterEach(func() {
+		tests.CollectArtifacts(blockchain)
 		blockchain.Stop(true) // This will return container not found error since we stop one
 		blockchain.Finalize()
 	})
@@ -74,7 +77,8 @@ var _ = Describe("TFS-03: Recoverability testing", func() {
 		By("The consensus should not work after resuming", func() {
 			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
 				// container.ErrNoBlock should be returned if we didn't see any block in 10 seconds
-				Expect(geth.WaitForBlocks(1, 10*time.Second)).To(BeEquivalentTo(container.ErrNoBlock))
+				err := geth.WaitForBlocks(1, 10*time.Second)
+				Expect(errors.Is(err, container.ErrNoBlock)).To(BeTrue(), "unexpected error %v", err)
 				wg.Done()
 			})
 		})
@@ -95,4 +99,117 @@ var _ = Describe("TFS-03: Recoverability testing", func() {
 
 		close(done)
 	}, 120)
+
+	It("TFS-03-02: Crash and recover a fullnode", func(done Done) {
+		By("Wait for blocks", func() {
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(3)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("Kill fullnode 0", func() {
+			Expect(blockchain.Fullnodes()[0].Kill()).To(BeNil())
+		})
+
+		By("The others should keep generating blocks", func() {
+			tests.WaitFor(blockchain.Fullnodes()[1:], func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(3)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("Restart fullnode 0 from its data dir", func() {
+			Expect(blockchain.RestartFullnode(blockchain.Fullnodes()[0])).To(BeNil())
+		})
+
+		By("The consensus should work after recovering", func() {
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(5)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("All fullnodes should agree on every block", func() {
+			expectSafety(blockchain.Fullnodes())
+		})
+
+		close(done)
+	}, 240)
+
+	It("TFS-03-03: Crash and recover more than F fullnodes", func(done Done) {
+		By("Wait for blocks", func() {
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(3)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("Kill fullnode 0 and 1", func() {
+			tests.WaitFor(blockchain.Fullnodes()[:2], func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.Kill()).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("The others should not generate blocks", func() {
+			tests.WaitFor(blockchain.Fullnodes()[2:], func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForNoBlocks(1, 20*time.Second)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("Restart fullnode 0 and 1 from their data dirs", func() {
+			for _, geth := range blockchain.Fullnodes()[:2] {
+				Expect(blockchain.RestartFullnode(geth)).To(BeNil())
+			}
+		})
+
+		By("The consensus should work after recovering", func() {
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(5)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("All fullnodes should agree on every block", func() {
+			expectSafety(blockchain.Fullnodes())
+		})
+
+		close(done)
+	}, 240)
+
+	It("TFS-03-04: Freeze a fullnode", func(done Done) {
+		By("Wait for blocks", func() {
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(3)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("Pause fullnode 0", func() {
+			Expect(blockchain.Fullnodes()[0].Pause()).To(BeNil())
+		})
+
+		By("The others should keep generating blocks", func() {
+			tests.WaitFor(blockchain.Fullnodes()[1:], func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(3)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("Unpause fullnode 0", func() {
+			Expect(blockchain.Fullnodes()[0].Unpause()).To(BeNil())
+		})
+
+		By("The unpaused fullnode should catch up", func() {
+			Expect(blockchain.Fullnodes()[0].WaitForBlocks(5)).To(BeNil())
+		})
+
+		By("All fullnodes should agree on every block", func() {
+			expectSafety(blockchain.Fullnodes())
+		})
+
+		close(done)
+	}, 240)
 })
@@ -0,0 +1,132 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package functional
+
+import (
+	"fmt"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	tests "go-smilo/src/blockchain/regression"
+	"go-smilo/src/blockchain/regression/src/container"
+)
+
+var _ = Describe("TFS-08: Rolling upgrade", func() {
+	const (
+		numberOfFullnodes = 4
+	)
+	var (
+		blockchain container.Blockchain
+		from, to   string
+		err        error
+	)
+
+	BeforeEach(func() {
+		from, to = tests.UpgradeTags()
+		tags := make([]string, numberOfFullnodes)
+		for i := range tags {
+			tags[i] = from
+		}
+		blockchain, err = container.NewDefaultBlockchainWithTags(dockerNetwork, tags)
+		Expect(err).To(BeNil())
+		Expect(blockchain).ToNot(BeNil())
+		Expect(blockchain.Start(container.FullMesh())).To(BeNil())
+	})
+
+	AfterEach(func() {
+		tests.CollectArtifacts(blockchain)
+		blockchain.Stop(true)
+		blockchain.Finalize()
+	})
+
+	waitForBlocks := func(num int) {
+		tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+			Expect(geth.WaitForBlocks(num)).To(BeNil())
+			wg.Done()
+		})
+	}
+
+	It("TFS-08-01: Upgrade the fullnodes one by one", func(done Done) {
+		By("Wait for blocks", func() {
+			waitForBlocks(3)
+		})
+
+		for i, geth := range blockchain.Fullnodes() {
+			By(fmt.Sprintf("Upgrade fullnode %d to %s", i, to), func() {
+				Expect(blockchain.Upgrade([]container.Ethereum{geth}, to)).To(BeNil())
+			})
+
+			By("The consensus should keep working", func() {
+				waitForBlocks(2)
+			})
+		}
+
+		By("All fullnodes should run the new image", func() {
+			for _, geth := range blockchain.Fullnodes() {
+				Expect(geth.Image()).To(HaveSuffix(":" + to))
+			}
+		})
+
+		By("All fullnodes should agree on every block", func() {
+			expectSafety(blockchain.Fullnodes())
+		})
+
+		close(done)
+	}, 600)
+
+	It("TFS-08-02: Roll back an upgrade", func(done Done) {
+		By("Upgrade all fullnodes", func() {
+			Expect(blockchain.Upgrade(blockchain.Fullnodes(), to)).To(BeNil())
+		})
+
+		By("The consensus should work after the upgrade", func() {
+			waitForBlocks(3)
+		})
+
+		By(fmt.Sprintf("Roll back all fullnodes to %s", from), func() {
+			Expect(blockchain.Upgrade(blockchain.Fullnodes(), from)).To(BeNil())
+		})
+
+		By("The consensus should work after the rollback", func() {
+			waitForBlocks(3)
+		})
+
+		By("All fullnodes should agree on every block", func() {
+			expectSafety(blockchain.Fullnodes())
+		})
+
+		close(done)
+	}, 600)
+
+	It("TFS-08-03: Mixed versions", func(done Done) {
+		By("Upgrade half of the fullnodes", func() {
+			Expect(blockchain.Upgrade(blockchain.Fullnodes()[:numberOfFullnodes/2], to)).To(BeNil())
+		})
+
+		By("The consensus should work with both versions", func() {
+			waitForBlocks(10)
+		})
+
+		By("All fullnodes should agree on every block", func() {
+			expectSafety(blockchain.Fullnodes())
+		})
+
+		close(done)
+	}, 300)
+})
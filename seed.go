@@ -0,0 +1,45 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package go_smilo_regression
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/onsi/ginkgo"
+
+	"go-smilo/src/blockchain/regression/src/common"
+)
+
+// SeedSpec seeds the keys and accounts of the current spec from the run seed
+// and the spec text, so that the spec builds the same network whichever specs
+// run before it. Call it from a top level BeforeEach.
+func SeedSpec() {
+	desc := ginkgo.CurrentGinkgoTestDescription()
+	common.SetSeed(common.DeriveSeed(desc.FullTestText))
+}
+
+// ReportSeed prints how to rebuild the network of the current spec if it
+// failed. Call it from a top level AfterEach.
+func ReportSeed() {
+	desc := ginkgo.CurrentGinkgoTestDescription()
+	if !desc.Failed {
+		return
+	}
+	fmt.Fprintf(ginkgo.GinkgoWriter, "Rebuild the network with %s=%d -ginkgo.focus=%q (spec seed %d)\n",
+		common.SeedEnv, common.RunSeed(), regexp.QuoteMeta(desc.FullTestText), common.Seed())
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package functional_test
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	tests "go-smilo/src/blockchain/regression"
+	"go-smilo/src/blockchain/regression/src/container"
+)
+
+var _ = Describe("SFS-10: Degraded network", func() {
+	const (
+		numberOfFullnodes = 4
+	)
+	var (
+		vaultNetwork container.VaultNetwork
+		blockchain   container.Blockchain
+		err          error
+	)
+
+	BeforeEach(func() {
+		vaultNetwork, err = container.NewDefaultVaultNetwork(dockerNetwork, numberOfFullnodes)
+		Expect(err).To(BeNil())
+		Expect(vaultNetwork).ToNot(BeNil())
+		Expect(vaultNetwork.Start()).To(BeNil())
+		blockchain, err = container.NewDefaultSmiloBlockchain(dockerNetwork, vaultNetwork)
+		Expect(err).To(BeNil())
+		Expect(blockchain).ToNot(BeNil())
+		Expect(blockchain.Start(container.FullMesh())).To(BeNil())
+	})
+
+	AfterEach(func() {
+		tests.CollectArtifacts(blockchain, vaultNetwork)
+		Expect(dockerNetwork.Unshape()).To(BeNil())
+		blockchain.Stop(true)
+		blockchain.Finalize()
+		vaultNetwork.Stop()
+		vaultNetwork.Finalize()
+	})
+
+	It("SFS-10-01: WAN latency and packet loss", func(done Done) {
+		wan := container.LinkShape{
+			Delay:  300 * time.Millisecond,
+			Jitter: 50 * time.Millisecond,
+			Loss:   5,
+		}
+
+		var vals []common.Address
+		for _, geth := range blockchain.Fullnodes() {
+			vals = append(vals, geth.Address())
+		}
+
+		By("Shape every fullnode as a WAN link", func() {
+			for _, geth := range blockchain.Fullnodes() {
+				Expect(dockerNetwork.Shape(geth, wan)).To(BeNil())
+			}
+		})
+
+		By("The consensus should keep working with few round changes", func() {
+			recorder := container.NewConsensusRecorder(blockchain.Fullnodes())
+			recorder.Start()
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(5, 2*time.Minute)).To(BeNil())
+				wg.Done()
+			})
+			recorder.Stop()
+
+			// Every block is agreed on before all fullnodes proposed it
+			rounds := recorder.RoundChanges(vals)
+			fmt.Fprintf(GinkgoWriter, "Round changes over 5 blocks of a WAN: %d\n", rounds)
+			Expect(rounds).To(BeNumerically("<", 5*len(vals)))
+		})
+
+		By("Restore normal links", func() {
+			Expect(dockerNetwork.Unshape()).To(BeNil())
+		})
+
+		By("Ensure that consensus is working in 30 seconds", func() {
+			Expect(blockchain.EnsureConsensusWorking(blockchain.Fullnodes(), 30*time.Second)).Should(BeNil())
+		})
+
+		By("The round changes stop with normal links", func() {
+			recorder := container.NewConsensusRecorder(blockchain.Fullnodes())
+			recorder.Start()
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(2*len(vals), time.Minute)).To(BeNil())
+				wg.Done()
+			})
+			recorder.Stop()
+			Expect(recorder.RoundChanges(vals)).To(BeNumerically("<=", 1))
+		})
+
+		close(done)
+	}, 300)
+
+	It("SFS-10-02: Slow link between two fullnodes", func(done Done) {
+		By("Slow down the link between fullnode 0 and fullnode 1", func() {
+			fullnodes := blockchain.Fullnodes()
+			Expect(dockerNetwork.ShapeLink(fullnodes[0], fullnodes[1], container.LinkShape{
+				Delay: time.Second,
+				Rate:  256,
+			})).To(BeNil())
+		})
+
+		By("The consensus should keep working", func() {
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(5, time.Minute)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		close(done)
+	}, 180)
+})
@@ -49,10 +49,11 @@ var _ = Describe("SFS-02: Dynamic fullnodes addition/removal testing", func() {
 		blockchain, err = container.NewDefaultSmiloBlockchain(dockerNetwork, vaultNetwork)
 		Expect(err).To(BeNil())
 		Expect(blockchain).ToNot(BeNil())
-		Expect(blockchain.Start(true)).To(BeNil())
+		Expect(blockchain.Start(container.FullMesh())).To(BeNil())
 	})
 
 	AfterEach(func() {
+		tests.CollectArtifacts(blockchain, vaultNetwork)
 		blockchain.Stop(true)
 		blockchain.Finalize()
 		vaultNetwork.Stop()
@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -54,10 +55,11 @@ var _ = Describe("SFS-01: General consensus", func() {
 		blockchain, err = container.NewDefaultSmiloBlockchain(dockerNetwork, vaultNetwork)
 		Expect(err).To(BeNil())
 		Expect(blockchain).ToNot(BeNil())
-		Expect(blockchain.Start(true)).To(BeNil())
+		Expect(blockchain.Start(container.FullMesh())).To(BeNil())
 	})
 
 	AfterEach(func() {
+		tests.CollectArtifacts(blockchain, vaultNetwork)
 		blockchain.Stop(true)
 		blockchain.Finalize()
 		vaultNetwork.Stop()
@@ -281,4 +283,40 @@ var _ = Describe("SFS-01: General consensus", func() {
 		})
 		close(done)
 	}, 120)
+
+	It("SFS-01-06: Consensus metrics", func(done Done) {
+		recorder := container.NewConsensusRecorder(blockchain.Fullnodes())
+		recorder.Start()
+
+		var vals []common.Address
+		for _, geth := range blockchain.Fullnodes() {
+			vals = append(vals, geth.Address())
+		}
+		c := blockchain.Fullnodes()[0].NewClient()
+		Expect(c).ToNot(BeNil())
+		startHeight, err := c.BlockNumber(context.Background())
+		Expect(err).To(BeNil())
+		c.Close()
+		// Every fullnode proposes twice in round robin
+		fromHeight := startHeight.Uint64() + 1
+		targetBlockHeight := int(startHeight.Int64()) + 2*len(vals)
+
+		By("Wait for consensus progress", func() {
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlockHeight(targetBlockHeight)).To(BeNil())
+				wg.Done()
+			})
+		})
+		recorder.Stop()
+
+		By("Check the recorded blocks", func() {
+			Expect(recorder.BlockTimePercentile(99)).To(BeNumerically("<", 4*time.Second))
+			Expect(recorder.Forks()).To(BeEmpty())
+			Expect(recorder.MissingProposers(vals, fromHeight, uint64(targetBlockHeight))).To(BeEmpty())
+			f := (len(vals) - 1) / 3
+			Expect(recorder.MinCommittedSeals()).To(BeNumerically(">=", 2*f+1))
+		})
+
+		close(done)
+	}, 120)
 })
@@ -20,7 +20,9 @@ package functional_test
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -47,10 +49,11 @@ var _ = Describe("SFS-07: Gossip Network", func() {
 		blockchain, err = container.NewDefaultSmiloBlockchain(dockerNetwork, vaultNetwork)
 		Expect(err).To(BeNil())
 		Expect(blockchain).ToNot(BeNil())
-		Expect(blockchain.Start(false)).To(BeNil())
+		Expect(blockchain.Start(container.Ring())).To(BeNil())
 	})
 
 	AfterEach(func() {
+		tests.CollectArtifacts(blockchain, vaultNetwork)
 		blockchain.Stop(true)
 		blockchain.Finalize()
 		vaultNetwork.Stop()
@@ -77,3 +80,83 @@ var _ = Describe("SFS-07: Gossip Network", func() {
 		close(done)
 	}, 240)
 })
+
+var _ = Describe("SFS-07: Gossip Network topologies", func() {
+	const (
+		numberOfFullnodes = 6
+	)
+	var (
+		vaultNetwork container.VaultNetwork
+		blockchain   container.Blockchain
+		err          error
+	)
+
+	BeforeEach(func() {
+		vaultNetwork, err = container.NewDefaultVaultNetwork(dockerNetwork, numberOfFullnodes)
+		Expect(err).To(BeNil())
+		Expect(vaultNetwork).ToNot(BeNil())
+		Expect(vaultNetwork.Start()).To(BeNil())
+		blockchain, err = container.NewDefaultSmiloBlockchain(dockerNetwork, vaultNetwork)
+		Expect(err).To(BeNil())
+		Expect(blockchain).ToNot(BeNil())
+	})
+
+	AfterEach(func() {
+		tests.CollectArtifacts(blockchain, vaultNetwork)
+		blockchain.Stop(true)
+		blockchain.Finalize()
+		vaultNetwork.Stop()
+		vaultNetwork.Finalize()
+	})
+
+	// A slice rather than a map keeps the spec order stable across runs
+	topologies := []struct {
+		name     string
+		topology container.Topology
+	}{
+		{"line", container.Line()},
+		{"star", container.Star(0)},
+		{"binary tree", container.Tree(2)},
+		{"random 3-regular", container.RandomRegular(3, 1)},
+		{"adjacency list", container.AdjacencyList{{1, 2}, {2}, {3}, {4}, {5}, {0}}},
+	}
+	for _, entry := range topologies {
+		name, topology := entry.name, entry.topology
+
+		It(fmt.Sprintf("SFS-07-02: Gossip over a %s", name), func(done Done) {
+			Expect(blockchain.Start(topology)).To(BeNil())
+
+			By("Check peers", func() {
+				Expect(blockchain.WaitForTopology(30 * time.Second)).To(BeNil())
+			})
+
+			By("Checking blockchain progress", func() {
+				tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+					Expect(geth.WaitForBlocks(3)).To(BeNil())
+					wg.Done()
+				})
+			})
+
+			close(done)
+		}, 240)
+	}
+
+	It("SFS-07-03: Rewire a running network", func(done Done) {
+		Expect(blockchain.Start(container.Line())).To(BeNil())
+
+		By("Rewire the line into a star", func() {
+			Expect(blockchain.WaitForTopology(30 * time.Second)).To(BeNil())
+			Expect(blockchain.Rewire(container.Star(0))).To(BeNil())
+			Expect(blockchain.WaitForTopology(30 * time.Second)).To(BeNil())
+		})
+
+		By("Checking blockchain progress", func() {
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(3)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		close(done)
+	}, 240)
+})
@@ -19,11 +19,13 @@
 package functional_test
 
 import (
+	"context"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	tests "go-smilo/src/blockchain/regression"
 	"go-smilo/src/blockchain/regression/src/container"
 )
 
@@ -42,7 +44,21 @@ var _ = BeforeSuite(func() {
 	Expect(err).To(BeNil())
 })
 
+var _ = BeforeEach(tests.SeedSpec)
+
+var _ = BeforeEach(tests.LogSpec)
+
+var _ = AfterEach(tests.ReportSeed)
+
 var _ = AfterSuite(func() {
-	err := dockerNetwork.Remove()
-	Expect(err).To(BeNil())
+	// Removes the docker network along with anything the specs leaked
+	Expect(tests.ReapRun()).To(BeNil())
 })
+
+// expectSafety fails the spec if the fullnodes disagree on any block or hold
+// blocks committed by fewer than 2F+1 fullnodes.
+func expectSafety(fullnodes []container.Ethereum) {
+	report, err := container.NewSafetyAuditor(fullnodes).Audit(context.Background())
+	ExpectWithOffset(1, err).To(BeNil())
+	ExpectWithOffset(1, report.Err()).To(BeNil())
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package functional_test
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	tests "go-smilo/src/blockchain/regression"
+	"go-smilo/src/blockchain/regression/src/container"
+)
+
+var _ = Describe("SFS-09: Network partition", func() {
+	const (
+		numberOfFullnodes = 4
+	)
+	var (
+		vaultNetwork container.VaultNetwork
+		blockchain   container.Blockchain
+		err          error
+	)
+
+	BeforeEach(func() {
+		vaultNetwork, err = container.NewDefaultVaultNetwork(dockerNetwork, numberOfFullnodes)
+		Expect(err).To(BeNil())
+		Expect(vaultNetwork).ToNot(BeNil())
+		Expect(vaultNetwork.Start()).To(BeNil())
+		blockchain, err = container.NewDefaultSmiloBlockchain(dockerNetwork, vaultNetwork)
+		Expect(err).To(BeNil())
+		Expect(blockchain).ToNot(BeNil())
+		Expect(blockchain.Start(container.FullMesh())).To(BeNil())
+	})
+
+	AfterEach(func() {
+		tests.CollectArtifacts(blockchain, vaultNetwork)
+		Expect(dockerNetwork.Heal()).To(BeNil())
+		blockchain.Stop(true)
+		blockchain.Finalize()
+		vaultNetwork.Stop()
+		vaultNetwork.Finalize()
+	})
+
+	It("SFS-09-01: Minority isolation", func(done Done) {
+		By("Wait for blocks", func() {
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(3)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("Isolate fullnode 0 from the others", func() {
+			fullnodes := blockchain.Fullnodes()
+			Expect(dockerNetwork.Partition(fullnodes[:1], fullnodes[1:])).To(BeNil())
+		})
+
+		By("The majority should keep generating blocks", func() {
+			tests.WaitFor(blockchain.Fullnodes()[1:], func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(5)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("The isolated fullnode should not see new blocks", func() {
+			// A block in flight when it was isolated may still arrive
+			Expect(blockchain.Fullnodes()[0].WaitForNoBlocks(1, 10*time.Second)).To(BeNil())
+		})
+
+		By("Heal the partition", func() {
+			Expect(dockerNetwork.Heal()).To(BeNil())
+		})
+
+		By("The isolated fullnode should catch up", func() {
+			Expect(blockchain.Fullnodes()[0].WaitForBlocks(5)).To(BeNil())
+		})
+
+		By("All fullnodes should agree on every block", func() {
+			expectSafety(blockchain.Fullnodes())
+		})
+
+		close(done)
+	}, 240)
+
+	It("SFS-09-02: Split brain", func(done Done) {
+		By("Wait for blocks", func() {
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(3)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("Split the fullnodes into two halves", func() {
+			fullnodes := blockchain.Fullnodes()
+			Expect(dockerNetwork.Partition(fullnodes[:2], fullnodes[2:])).To(BeNil())
+		})
+
+		By("No half should generate blocks", func() {
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForNoBlocks(1, 20*time.Second)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("Heal the partition", func() {
+			Expect(dockerNetwork.Heal()).To(BeNil())
+		})
+
+		By("The consensus should work after re-merging", func() {
+			tests.WaitFor(blockchain.Fullnodes(), func(geth container.Ethereum, wg *sync.WaitGroup) {
+				Expect(geth.WaitForBlocks(5)).To(BeNil())
+				wg.Done()
+			})
+		})
+
+		By("All fullnodes should agree on every block", func() {
+			expectSafety(blockchain.Fullnodes())
+		})
+
+		close(done)
+	}, 240)
+})
@@ -30,6 +30,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 
+	tests "go-smilo/src/blockchain/regression"
 	"go-smilo/src/blockchain/regression/src/client"
 	"go-smilo/src/blockchain/regression/src/container"
 	"go-smilo/src/blockchain/smilobft/core/types"
@@ -57,10 +58,11 @@ var _ = Describe("SFS-08: Private transaction", func() {
 		blockchain, err = container.NewDefaultSmiloBlockchain(dockerNetwork, vaultNetwork)
 		Expect(err).To(BeNil())
 		Expect(blockchain).ToNot(BeNil())
-		Expect(blockchain.Start(true)).To(BeNil())
+		Expect(blockchain.Start(container.FullMesh())).To(BeNil())
 	})
 
 	AfterEach(func() {
+		tests.CollectArtifacts(blockchain, vaultNetwork)
 		blockchain.Stop(true)
 //		blockchain.Finalize()
 		vaultNetwork.Stop()
@@ -0,0 +1,65 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package functional_test
+
+import (
+	"path/filepath"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	tests "go-smilo/src/blockchain/regression"
+	"go-smilo/src/blockchain/regression/src/container"
+)
+
+// Every file in topologies/ is loaded and its first fullnode is expected to
+// generate blocks, new scenarios only need a new file.
+var _ = Describe("SFS-11: Topology files", func() {
+	files, _ := filepath.Glob(filepath.Join("topologies", "*"))
+
+	for _, file := range files {
+		file := file
+
+		Context(filepath.Base(file), func() {
+			var blockchain container.Blockchain
+
+			BeforeEach(func() {
+				var err error
+				blockchain, err = container.LoadTopology(file)
+				Expect(err).To(BeNil())
+				Expect(blockchain).ToNot(BeNil())
+				Expect(blockchain.Start(nil)).To(BeNil())
+			})
+
+			AfterEach(func() {
+				tests.CollectArtifacts(blockchain)
+				blockchain.Stop(true)
+				blockchain.Finalize()
+			})
+
+			It("Should generate blocks", func(done Done) {
+				tests.WaitFor(blockchain.Fullnodes()[:1], func(geth container.Ethereum, wg *sync.WaitGroup) {
+					Expect(geth.WaitForBlocks(3)).To(BeNil())
+					wg.Done()
+				})
+
+				close(done)
+			}, 180)
+		})
+	}
+})
@@ -29,9 +29,35 @@ import (
 	"go-smilo/src/blockchain/smilobft/p2p"
 )
 
+// Snapshot is the state of the Sport votes at a block.
+type Snapshot struct {
+	Epoch     uint64                   `json:"epoch"`
+	Number    uint64                   `json:"number"`
+	Hash      common.Hash              `json:"hash"`
+	Votes     []*Vote                  `json:"votes"`
+	Tally     map[common.Address]Tally `json:"tally"`
+	Fullnodes []common.Address         `json:"fullnodes"`
+	Policy    uint64                   `json:"policy"`
+}
+
+// Vote is the vote of a fullnode to add or remove the address.
+type Vote struct {
+	Fullnode  common.Address `json:"fullnode"`
+	Block     uint64         `json:"block"`
+	Address   common.Address `json:"address"`
+	Authorize bool           `json:"authorize"`
+}
+
+// Tally counts the votes for an address.
+type Tally struct {
+	Authorize bool `json:"authorize"`
+	Votes     int  `json:"votes"`
+}
+
 type Client interface {
 	Close()
 	AddPeer(ctx context.Context, nodeURL string) error
+	RemovePeer(ctx context.Context, nodeURL string) error
 	AdminPeers(ctx context.Context) ([]*p2p.PeerInfo, error)
 	NodeInfo(ctx context.Context) (*p2p.PeerInfo, error)
 	BlockNumber(ctx context.Context) (*big.Int, error)
@@ -41,7 +67,15 @@ type Client interface {
 	CreateContract(ctx context.Context, from common.Address, bytecode string, gas *big.Int) (string, error)
 	CreatePrivateContract(ctx context.Context, from common.Address, bytecode string, gas *big.Int, privateFor []string) (string, error)
 	ProposeFullnode(ctx context.Context, address common.Address, auth bool) error
+	// DiscardProposal drops the pending proposal of the node for the address
+	DiscardProposal(ctx context.Context, address common.Address) error
+	// Candidates are the pending proposals of the node, true adds a fullnode
+	Candidates(ctx context.Context) (map[common.Address]bool, error)
+	// The fullnodes are sorted, a nil block number is the latest block
 	GetFullnodes(ctx context.Context, blockNumber *big.Int) ([]common.Address, error)
+	GetFullnodesAtHash(ctx context.Context, hash common.Hash) ([]common.Address, error)
+	GetSnapshot(ctx context.Context, blockNumber *big.Int) (*Snapshot, error)
+	GetSnapshotAtHash(ctx context.Context, hash common.Hash) (*Snapshot, error)
 
 	// eth client
 	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
@@ -20,7 +20,6 @@ package client
 
 import (
 	"context"
-	"fmt"
 	"math/big"
 	"sort"
 	"strings"
@@ -67,6 +66,11 @@ func (ic *client) AddPeer(ctx context.Context, nodeURL string) error {
 	return err
 }
 
+func (ic *client) RemovePeer(ctx context.Context, nodeURL string) error {
+	var r bool
+	return ic.c.CallContext(ctx, &r, "admin_removePeer", nodeURL)
+}
+
 func (ic *client) AdminPeers(ctx context.Context) ([]*p2p.PeerInfo, error) {
 	var r []*p2p.PeerInfo
 	// The response data type are bytes, but we cannot parse...
@@ -165,17 +169,51 @@ func (ic *client) CreatePrivateContract(ctx context.Context, from common.Address
 // ----------------------------------------------------------------------------
 
 func (ic *client) ProposeFullnode(ctx context.Context, address common.Address, auth bool) error {
-	var r []byte
-	// TODO: Result needs to be verified with other method
-	// The response data type are bytes, but we cannot parse...
-	err := ic.c.CallContext(ctx, &r, "smilobft_propose", address, auth)
+	err := ic.c.CallContext(ctx, nil, "smilobft_propose", address, auth)
+	if err != nil {
+		log.Error("Could not smilobft_propose", "address", address, "err", err)
+	}
+	return err
+}
+
+func (ic *client) DiscardProposal(ctx context.Context, address common.Address) error {
+	err := ic.c.CallContext(ctx, nil, "smilobft_discard", address)
 	if err != nil {
-		fmt.Println("ERROR: test_client, Could not smilobft_propose", err)
-		return ethereum.NotFound
+		log.Error("Could not smilobft_discard", "address", address, "err", err)
 	}
 	return err
 }
 
+func (ic *client) Candidates(ctx context.Context) (map[common.Address]bool, error) {
+	var r map[common.Address]bool
+	if err := ic.c.CallContext(ctx, &r, "smilobft_candidates"); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (ic *client) GetSnapshot(ctx context.Context, blockNumber *big.Int) (*Snapshot, error) {
+	var r *Snapshot
+	if err := ic.c.CallContext(ctx, &r, "smilobft_getSnapshot", toNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ethereum.NotFound
+	}
+	return r, nil
+}
+
+func (ic *client) GetSnapshotAtHash(ctx context.Context, hash common.Hash) (*Snapshot, error) {
+	var r *Snapshot
+	if err := ic.c.CallContext(ctx, &r, "smilobft_getSnapshotAtHash", hash); err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ethereum.NotFound
+	}
+	return r, nil
+}
+
 type addresses []common.Address
 
 func (addrs addresses) Len() int {
@@ -194,7 +232,7 @@ func (ic *client) GetFullnodes(ctx context.Context, blockNumber *big.Int) ([]com
 	var r []common.Address
 	err := ic.c.CallContext(ctx, &r, "smilobft_getFullnodes", toNumArg(blockNumber))
 	if err == nil && r == nil {
-		fmt.Println("ERROR: test_client, Could not smilobft_getFullnodes", err)
+		log.Error("Could not smilobft_getFullnodes", "number", toNumArg(blockNumber))
 		return nil, ethereum.NotFound
 	}
 
@@ -203,9 +241,23 @@ func (ic *client) GetFullnodes(ctx context.Context, blockNumber *big.Int) ([]com
 	return r, err
 }
 
+func (ic *client) GetFullnodesAtHash(ctx context.Context, hash common.Hash) ([]common.Address, error) {
+	var r []common.Address
+	if err := ic.c.CallContext(ctx, &r, "smilobft_getFullnodesAtHash", hash); err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ethereum.NotFound
+	}
+
+	sort.Sort(addresses(r))
+
+	return r, nil
+}
+
 func toNumArg(number *big.Int) string {
 	if number == nil {
-		return ""
+		return "latest"
 	}
 	return hexutil.EncodeBig(number)
 }
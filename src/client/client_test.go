@@ -108,3 +108,21 @@ func ExampleAddPeer() {
 		return
 	}
 }
+
+func ExampleGetSnapshot() {
+	url := "ws://127.0.0.1:53257"
+	client, err := Dial(url)
+	if err != nil {
+		log.Error("Failed to dial", "url", url, "err", err)
+		return
+	}
+
+	snapshot, err := client.GetSnapshot(context.Background(), nil)
+	if err != nil {
+		log.Error("Failed to get snapshot", "err", err)
+		return
+	}
+	for addr, tally := range snapshot.Tally {
+		log.Info("tally", "address", addr.Hex(), "authorize", tally.Authorize, "votes", tally.Votes)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RunIDEnv sets the id of a regression run, a new id is generated for every
+// process if it is not set. The processes of a parallel run share the id,
+// each of them reaps only the resources it created, see ProcessID.
+const RunIDEnv = "REGRESSION_RUN_ID"
+
+const keystorePrefix = "sport-keystore"
+
+var (
+	runID     = newRunID()
+	processID = newProcessID()
+)
+
+func newRunID() string {
+	if id := os.Getenv(RunIDEnv); id != "" {
+		return id
+	}
+	return fmt.Sprintf("%d-%d", time.Now().Unix(), os.Getpid())
+}
+
+func newProcessID() string {
+	if os.Getenv(RunIDEnv) != "" {
+		return fmt.Sprintf("%s-%d", runID, os.Getpid())
+	}
+	return runID
+}
+
+// RunID returns the id of the regression run, which labels the docker
+// resources it creates.
+func RunID() string {
+	return runID
+}
+
+// ProcessID returns the id of the resources of this process, it starts with
+// the run id and names the temp dirs it creates. It is the run id unless
+// REGRESSION_RUN_ID is set, which the processes of a parallel run share.
+func ProcessID() string {
+	return processID
+}
+
+// GenerateKeystoreDir creates a temp dir for the keystore of a blockchain.
+func GenerateKeystoreDir() (string, error) {
+	return ioutil.TempDir("", fmt.Sprintf("%s-%s-", keystorePrefix, processID))
+}
+
+// TempDirs returns the data dirs and keystores created by the run, or by all
+// runs if runID is empty. A process id selects the dirs of that process.
+func TempDirs(runID string) ([]string, error) {
+	patterns := []string{
+		filepath.Join(defaultLocalDir, clientIdentifier+"-"+runID+"-*"),
+		filepath.Join(os.TempDir(), keystorePrefix+"-"+runID+"-*"),
+	}
+	if runID == "" {
+		// Include the dirs of runs without ids
+		patterns = []string{
+			filepath.Join(defaultLocalDir, clientIdentifier+"-*"),
+			filepath.Join(os.TempDir(), keystorePrefix+"*"),
+		}
+	}
+
+	var dirs []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, matches...)
+	}
+	return dirs, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	mrand "math/rand"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SeedEnv fixes the seed of a regression run, a random seed is used if it is
+// not set.
+const SeedEnv = "REGRESSION_SEED"
+
+// SeedTimestamp is the genesis timestamp of every network, so that genesis
+// blocks only depend on the seed.
+const SeedTimestamp = 1577836800
+
+var (
+	runSeed int64
+
+	seedMutex sync.Mutex
+	seed      int64
+	seedRand  *mrand.Rand
+)
+
+func init() {
+	s, err := seedFromEnv()
+	if err != nil {
+		panic(fmt.Sprintf("invalid %s: %v", SeedEnv, err))
+	}
+	runSeed = s
+	SetSeed(s)
+}
+
+func seedFromEnv() (int64, error) {
+	if v := os.Getenv(SeedEnv); v != "" {
+		return strconv.ParseInt(v, 10, 64)
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b) >> 1), nil
+}
+
+// RunSeed returns the seed of the run, which is REGRESSION_SEED if it is set.
+func RunSeed() int64 {
+	return runSeed
+}
+
+// DeriveSeed returns a seed for label derived from the run seed.
+func DeriveSeed(label string) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d/%s", runSeed, label)
+	return int64(h.Sum64() >> 1)
+}
+
+// SetSeed restarts the generation of keys and accounts from s.
+func SetSeed(s int64) {
+	seedMutex.Lock()
+	defer seedMutex.Unlock()
+	seed = s
+	seedRand = mrand.New(mrand.NewSource(s))
+}
+
+// Seed returns the last seed passed to SetSeed.
+func Seed() int64 {
+	seedMutex.Lock()
+	defer seedMutex.Unlock()
+	return seed
+}
+
+// GenerateKey returns the next private key of the seeded sequence.
+func GenerateKey() (*ecdsa.PrivateKey, error) {
+	for {
+		b, _ := RandomBytes(32)
+		key, err := crypto.ToECDSA(b)
+		if err == nil {
+			return key, nil
+		}
+		// Retry the rare bytes out of the range of the curve
+	}
+}
+
+func seededRead(b []byte) {
+	seedMutex.Lock()
+	defer seedMutex.Unlock()
+	seedRand.Read(b)
+}
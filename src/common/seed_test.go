@@ -0,0 +1,59 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeededKeys(t *testing.T) {
+	defer SetSeed(Seed())
+
+	SetSeed(42)
+	keys, nodekeys, addrs := GenerateKeys(3)
+	SetSeed(42)
+	keys2, nodekeys2, addrs2 := GenerateKeys(3)
+
+	if !reflect.DeepEqual(nodekeys, nodekeys2) || !reflect.DeepEqual(addrs, addrs2) {
+		t.Errorf("keys mismatch: have %v, want %v", addrs2, addrs)
+	}
+	if len(keys) != 3 || len(keys2) != 3 {
+		t.Fatalf("number of keys mismatch: have %d, want %d", len(keys2), 3)
+	}
+	if addrs[0] == addrs[1] || addrs[1] == addrs[2] {
+		t.Errorf("duplicate addresses %v", addrs)
+	}
+
+	SetSeed(43)
+	_, _, addrs3 := GenerateKeys(1)
+	if addrs3[0] == addrs[0] {
+		t.Errorf("seeds 42 and 43 generated the same address %s", addrs[0].Hex())
+	}
+}
+
+func TestDeriveSeed(t *testing.T) {
+	if DeriveSeed("spec") != DeriveSeed("spec") {
+		t.Errorf("derived seeds of the same label mismatch")
+	}
+	if DeriveSeed("spec") == DeriveSeed("other spec") {
+		t.Errorf("derived seeds of different labels match")
+	}
+	if DeriveSeed("spec") < 0 {
+		t.Errorf("negative derived seed %d", DeriveSeed("spec"))
+	}
+}
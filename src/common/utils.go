@@ -20,7 +20,7 @@ package common
 
 import (
 	"crypto/ecdsa"
-	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -47,7 +47,7 @@ func GenerateRandomDir() (string, error) {
 	}
 
 	myuuid, _ := uuid.NewV4()
-	instanceDir := filepath.Join(defaultLocalDir, fmt.Sprintf("%s-%s", clientIdentifier, myuuid))
+	instanceDir := filepath.Join(defaultLocalDir, fmt.Sprintf("%s-%s-%s", clientIdentifier, processID, myuuid))
 	if err := os.MkdirAll(instanceDir, 0700); err != nil {
 		log.Error("Failed to create dir", "dir", instanceDir, "err", err)
 		return "", err
@@ -81,15 +81,13 @@ func CopyKeystore(dir string, accounts []accounts.Account) {
 
 func GenerateKeys(num int) (keys []*ecdsa.PrivateKey, nodekeys []string, addrs []common.Address) {
 	for i := 0; i < num; i++ {
-		nodekey := RandomHex()[2:]
-		nodekeys = append(nodekeys, nodekey)
-
-		key, err := crypto.HexToECDSA(nodekey)
+		key, err := GenerateKey()
 		if err != nil {
 			log.Error("Failed to generate key", "err", err)
 			return nil, nil, nil
 		}
 		keys = append(keys, key)
+		nodekeys = append(nodekeys, hex.EncodeToString(crypto.FromECDSA(key)))
 
 		addr := crypto.PubkeyToAddress(key.PublicKey)
 		addrs = append(addrs, addr)
@@ -118,9 +116,10 @@ func RandomHex() string {
 	return common.BytesToHash(b).Hex()
 }
 
+// RandomBytes returns the next bytes of the seeded sequence.
 func RandomBytes(len int) ([]byte, error) {
 	b := make([]byte, len)
-	_, _ = rand.Read(b)
+	seededRead(b)
 
 	return b, nil
 }
@@ -0,0 +1,219 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/phayes/freeport"
+)
+
+const (
+	// AllocatorDirEnv is the directory of the allocations shared by the
+	// processes of a parallel run, the temp dir is used if it is not set.
+	AllocatorDirEnv = "REGRESSION_ALLOCATOR_DIR"
+
+	allocationsFile = "regression-allocations.json"
+	lastSecondOctet = 31
+	portRetryCount  = 100
+)
+
+var errNoFreeSubnet = errors.New("no free subnet")
+
+// allocations are the subnets and host ports reserved by each process.
+type allocations struct {
+	Subnets map[string]int `json:"subnets"`
+	Ports   map[int]int    `json:"ports"`
+}
+
+// allocator hands out subnets and host ports which do not overlap across the
+// processes of a run, e.g. the nodes of ginkgo -p. The reservations are kept
+// in a file under an exclusive flock, those of dead processes are released.
+type allocator struct {
+	path string
+
+	mutex sync.Mutex
+	// rejected are the subnets docker refused to this process, e.g. because
+	// a network outside of the run uses them
+	rejected map[string]bool
+}
+
+var defaultAllocator = newAllocator(allocatorDir())
+
+func allocatorDir() string {
+	if dir := os.Getenv(AllocatorDirEnv); dir != "" {
+		return dir
+	}
+	return os.TempDir()
+}
+
+func newAllocator(dir string) *allocator {
+	return &allocator{
+		path:     filepath.Join(dir, allocationsFile),
+		rejected: make(map[string]bool),
+	}
+}
+
+// Subnet reserves the first /16 subnet from 172.18.0.0 to 172.31.0.0 no
+// process of the run holds.
+func (a *allocator) Subnet() (string, error) {
+	var subnet string
+	err := a.update(func(state *allocations) error {
+		for octet := SecondOctet + 1; octet <= lastSecondOctet; octet++ {
+			s := fmt.Sprintf("%d.%d.0.0/16", FirstOctet, octet)
+			if _, ok := state.Subnets[s]; ok || a.rejected[s] {
+				continue
+			}
+			state.Subnets[s] = os.Getpid()
+			subnet = s
+			return nil
+		}
+		return errNoFreeSubnet
+	})
+	return subnet, err
+}
+
+// RejectSubnet releases a subnet docker refused and skips it from now on.
+func (a *allocator) RejectSubnet(subnet string) error {
+	a.mutex.Lock()
+	a.rejected[subnet] = true
+	a.mutex.Unlock()
+	return a.ReleaseSubnet(subnet)
+}
+
+func (a *allocator) ReleaseSubnet(subnet string) error {
+	return a.update(func(state *allocations) error {
+		if state.Subnets[subnet] == os.Getpid() {
+			delete(state.Subnets, subnet)
+		}
+		return nil
+	})
+}
+
+// Port reserves a free host port no process of the run holds. Ports are
+// released when the process exits.
+func (a *allocator) Port() (int, error) {
+	var port int
+	err := a.update(func(state *allocations) error {
+		for i := 0; i < portRetryCount; i++ {
+			p, err := freeport.GetFreePort()
+			if err != nil {
+				return err
+			}
+			if _, ok := state.Ports[p]; ok {
+				continue
+			}
+			state.Ports[p] = os.Getpid()
+			port = p
+			return nil
+		}
+		return fmt.Errorf("no free port after %d attempts", portRetryCount)
+	})
+	return port, err
+}
+
+// update applies fn to the allocations under the lock of the file.
+func (a *allocator) update(fn func(*allocations) error) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		return err
+	}
+	lock, err := os.OpenFile(a.path+".lock", os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(lock.Fd()), syscall.LOCK_UN)
+
+	state := &allocations{}
+	raw, err := ioutil.ReadFile(a.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, state); err != nil {
+			log.Warn("Discarding corrupt allocations", "file", a.path, "err", err)
+			state = &allocations{}
+		}
+	}
+	state.prune()
+
+	if err := fn(state); err != nil {
+		return err
+	}
+
+	raw, err = json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := a.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, raw, 0666); err != nil {
+		return err
+	}
+	return os.Rename(tmp, a.path)
+}
+
+// prune releases the reservations of processes which exited.
+func (state *allocations) prune() {
+	if state.Subnets == nil {
+		state.Subnets = make(map[string]int)
+	}
+	if state.Ports == nil {
+		state.Ports = make(map[int]int)
+	}
+	for subnet, pid := range state.Subnets {
+		if !processAlive(pid) {
+			delete(state.Subnets, subnet)
+		}
+	}
+	for port, pid := range state.Ports {
+		if !processAlive(pid) {
+			delete(state.Ports, port)
+		}
+	}
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// freePort returns a host port reserved for this process, it falls back to
+// any free port if the allocations cannot be updated.
+func freePort() int {
+	port, err := defaultAllocator.Port()
+	if err != nil {
+		log.Error("Failed to reserve a port, using any free port", "err", err)
+		return freeport.GetPort()
+	}
+	return port
+}
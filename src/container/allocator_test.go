@@ -0,0 +1,119 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAllocatorSubnets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "allocator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Two allocators share the reservations like two processes
+	a1, a2 := newAllocator(dir), newAllocator(dir)
+	s1, err := a1.Subnet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := a2.Subnet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s1 != "172.18.0.0/16" || s2 != "172.19.0.0/16" {
+		t.Errorf("subnets mismatch: have %s and %s, want 172.18.0.0/16 and 172.19.0.0/16", s1, s2)
+	}
+
+	if err := a1.ReleaseSubnet(s1); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := a2.Subnet(); err != nil || s != s1 {
+		t.Errorf("released subnet mismatch: have %s, want %s, err %v", s, s1, err)
+	}
+
+	// A rejected subnet is skipped by its allocator only
+	if err := a1.RejectSubnet(s2); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := a1.Subnet(); err != nil || s != "172.20.0.0/16" {
+		t.Errorf("subnet after rejection mismatch: have %s, want 172.20.0.0/16, err %v", s, err)
+	}
+	if s, err := a2.Subnet(); err != nil || s != s2 {
+		t.Errorf("subnet rejected by another allocator mismatch: have %s, want %s, err %v", s, s2, err)
+	}
+
+	for {
+		if _, err := a1.Subnet(); err != nil {
+			if err != errNoFreeSubnet {
+				t.Fatal(err)
+			}
+			break
+		}
+	}
+}
+
+func TestAllocatorPrunesDeadProcesses(t *testing.T) {
+	dir, err := ioutil.TempDir("", "allocator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A pid above the maximum of Linux cannot be alive
+	raw, _ := json.Marshal(&allocations{
+		Subnets: map[string]int{"172.18.0.0/16": 1 << 23},
+		Ports:   map[int]int{30303: 1 << 23},
+	})
+	if err := ioutil.WriteFile(filepath.Join(dir, allocationsFile), raw, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	a := newAllocator(dir)
+	if s, err := a.Subnet(); err != nil || s != "172.18.0.0/16" {
+		t.Errorf("subnet of a dead process was not released: have %s, err %v", s, err)
+	}
+}
+
+func TestAllocatorPorts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "allocator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a1, a2 := newAllocator(dir), newAllocator(dir)
+	ports := make(map[int]bool)
+	for i := 0; i < 20; i++ {
+		for _, a := range []*allocator{a1, a2} {
+			port, err := a.Port()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ports[port] {
+				t.Fatalf("port %d was handed out twice", port)
+			}
+			ports[port] = true
+		}
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	ethtypes "go-smilo/src/blockchain/smilobft/core/types"
+)
+
+const (
+	// ArtifactsDirEnv is the directory artifacts of failed specs are saved
+	// to, a directory in the temp dir is used if it is not set.
+	ArtifactsDirEnv = "REGRESSION_ARTIFACTS_DIR"
+	// ArtifactsArchiveEnv packs the artifacts of a spec into a tarball if it
+	// is set.
+	ArtifactsArchiveEnv = "REGRESSION_ARTIFACTS_ARCHIVE"
+
+	artifactsTimeout = 30 * time.Second
+	// artifactsHeaders is the number of recent headers collected, a long
+	// chain is not fetched from the genesis
+	artifactsHeaders = 256
+)
+
+// ArtifactCollector saves the state of nodes for debugging a failure. It is
+// called before the nodes are stopped, since stopping them removes their
+// containers and logs.
+type ArtifactCollector interface {
+	CollectArtifacts(dir string) error
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ArtifactsDir returns the directory of the artifacts of the named spec.
+func ArtifactsDir(name string) string {
+	base := os.Getenv(ArtifactsDirEnv)
+	if base == "" {
+		base = filepath.Join(os.TempDir(), "regression-artifacts")
+	}
+	name = strings.Trim(unsafeNameChars.ReplaceAllString(name, "_"), "_")
+	return filepath.Join(base, fmt.Sprintf("%s-%d", name, time.Now().Unix()))
+}
+
+// CollectArtifacts saves the logs, node info, peers and header chain of the
+// fullnode, and a copy of its data dir, into dir.
+func (eth *ethereum) CollectArtifacts(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	errs := &artifactErrors{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), artifactsTimeout)
+	defer cancel()
+
+	if eth.containerID != "" {
+		errs.add("logs", writeArtifact(filepath.Join(dir, "geth.log"), func(w io.Writer) error {
+			return eth.runtime.Logs(ctx, eth.containerID, false, w, w)
+		}))
+	}
+
+	if cli := eth.NewClient(); cli != nil {
+		defer cli.Close()
+
+		info, err := cli.NodeInfo(ctx)
+		errs.add("node info", err)
+		if err == nil {
+			errs.add("node info", writeJSONArtifact(filepath.Join(dir, "node_info.json"), info))
+		}
+
+		peers, err := cli.AdminPeers(ctx)
+		errs.add("peers", err)
+		if err == nil {
+			errs.add("peers", writeJSONArtifact(filepath.Join(dir, "peers.json"), peers))
+		}
+
+		// The headers have their own deadline to not starve the other RPCs
+		hctx, hcancel := context.WithTimeout(context.Background(), artifactsTimeout)
+		headers, err := headerChain(hctx, cli.HeaderByNumber, artifactsHeaders)
+		hcancel()
+		errs.add("headers", err)
+		errs.add("headers", writeJSONArtifact(filepath.Join(dir, "headers.json"), headers))
+	} else {
+		errs.add("client", fmt.Errorf("failed to dial %s", eth.Host()))
+	}
+
+	if eth.dataDir != "" {
+		errs.add("data dir", copyDir(eth.dataDir, filepath.Join(dir, "datadir")))
+	}
+	return errs.err(dir)
+}
+
+// CollectArtifacts saves the logs and the work dir of the vault into dir.
+func (ct *vault) CollectArtifacts(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	errs := &artifactErrors{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), artifactsTimeout)
+	defer cancel()
+
+	if ct.containerID != "" {
+		errs.add("logs", writeArtifact(filepath.Join(dir, "vault.log"), func(w io.Writer) error {
+			return ct.runtime.Logs(ctx, ct.containerID, false, w, w)
+		}))
+	}
+	if ct.localWorkDir != "" {
+		errs.add("work dir", copyDir(ct.localWorkDir, filepath.Join(dir, "workdir")))
+	}
+	return errs.err(dir)
+}
+
+// CollectArtifacts saves the genesis file and the artifacts of every fullnode
+// into dir.
+func (bc *blockchain) CollectArtifacts(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	errs := &artifactErrors{}
+
+	if bc.genesisFile != "" {
+		errs.add("genesis", copyFile(bc.genesisFile, filepath.Join(dir, filepath.Base(bc.genesisFile))))
+	}
+	// Fullnodes and observers are named fullnode-<index> and observer-<index>
+	// by the blockchain
+	for _, geth := range append(bc.members.snapshot(), bc.observers.snapshot()...) {
+		errs.add(geth.Name(), geth.CollectArtifacts(filepath.Join(dir, geth.Name())))
+	}
+	return errs.err(dir)
+}
+
+// CollectArtifacts saves the artifacts of every vault into dir.
+func (ctn *vaultNetwork) CollectArtifacts(dir string) error {
+	errs := &artifactErrors{}
+	for i, ct := range ctn.vaults {
+		errs.add(fmt.Sprintf("vault %d", i), ct.CollectArtifacts(filepath.Join(dir, fmt.Sprintf("vault-%d", i))))
+	}
+	return errs.err(dir)
+}
+
+// ArchiveArtifacts packs dir into dir.tar.gz and removes dir.
+func ArchiveArtifacts(dir string) (string, error) {
+	path := strings.TrimSuffix(dir, string(filepath.Separator)) + ".tar.gz"
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	err = filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(filepath.Dir(dir), file)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		src, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gw.Close(); err != nil {
+		return "", err
+	}
+	return path, os.RemoveAll(dir)
+}
+
+// ----------------------------------------------------------------------------
+
+// artifactErrors gathers the failures of a collection, which carries on to
+// save as much as possible.
+type artifactErrors struct {
+	msgs []string
+}
+
+func (e *artifactErrors) add(what string, err error) {
+	if err == nil {
+		return
+	}
+	log.Error("Failed to collect artifacts", "artifact", what, "err", err)
+	e.msgs = append(e.msgs, fmt.Sprintf("%s: %v", what, err))
+}
+
+func (e *artifactErrors) err(dir string) error {
+	if len(e.msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("failed to collect artifacts into %s: %s", dir, strings.Join(e.msgs, "; "))
+}
+
+// headerChain returns the last count headers up to the head, or the headers
+// from the genesis if the chain is shorter.
+func headerChain(ctx context.Context, headerByNumber func(context.Context, *big.Int) (*ethtypes.Header, error), count uint64) ([]*ethtypes.Header, error) {
+	head, err := headerByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	var first uint64
+	if height := head.Number.Uint64(); height >= count {
+		first = height - count + 1
+	}
+	headers := make([]*ethtypes.Header, 0, head.Number.Uint64()-first+1)
+	for number := first; number < head.Number.Uint64(); number++ {
+		header, err := headerByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return headers, err
+		}
+		headers = append(headers, header)
+	}
+	return append(headers, head), nil
+}
+
+func writeArtifact(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return write(f)
+}
+
+func writeJSONArtifact(path string, v interface{}) error {
+	return writeArtifact(path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	})
+}
+
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0755)
+		case info.Mode().IsRegular():
+			return copyFile(path, target)
+		default:
+			// Skip sockets and other special files
+			return nil
+		}
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
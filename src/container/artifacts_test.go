@@ -0,0 +1,145 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ethtypes "go-smilo/src/blockchain/smilobft/core/types"
+)
+
+func TestCollectArtifacts(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 2)
+	defer finalizeTestBlockchain(bc)
+	defer bc.Stop(true)
+
+	if err := bc.Start(nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range bc.Fullnodes() {
+		rt.backend(v.IP()).Mine(3)
+	}
+
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	specDir := filepath.Join(dir, "spec")
+
+	if err := bc.CollectArtifacts(specDir); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []string{
+		filepath.Base(bc.genesisFile),
+		"fullnode-0/geth.log",
+		"fullnode-0/node_info.json",
+		"fullnode-0/peers.json",
+		"fullnode-1/headers.json",
+		"fullnode-1/datadir/geth/nodekey",
+	}
+	for _, f := range files {
+		if _, err := os.Stat(filepath.Join(specDir, f)); err != nil {
+			t.Errorf("missing artifact %s: %v", f, err)
+		}
+	}
+
+	raw, err := ioutil.ReadFile(filepath.Join(specDir, "fullnode-0", "headers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var headers []*ethtypes.Header
+	if err := json.Unmarshal(raw, &headers); err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != 4 {
+		t.Fatalf("number of headers mismatch: have %d, want %d", len(headers), 4)
+	}
+	for i := 1; i < len(headers); i++ {
+		if headers[i].ParentHash != headers[i-1].Hash() {
+			t.Errorf("header %d does not follow its parent", i)
+		}
+	}
+
+	archive, err := ArchiveArtifacts(specDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archive != specDir+".tar.gz" {
+		t.Errorf("archive mismatch: have %s, want %s", archive, specDir+".tar.gz")
+	}
+	if _, err := os.Stat(archive); err != nil {
+		t.Errorf("missing archive: %v", err)
+	}
+	if _, err := os.Stat(specDir); !os.IsNotExist(err) {
+		t.Errorf("artifacts dir was not removed: %v", err)
+	}
+}
+
+func TestHeaderChainWindow(t *testing.T) {
+	chain := []*ethtypes.Header{newFakeHeader(nil, nil)}
+	for len(chain) < 10 {
+		chain = append(chain, newFakeHeader(chain[len(chain)-1], nil))
+	}
+	headerByNumber := func(ctx context.Context, number *big.Int) (*ethtypes.Header, error) {
+		if number == nil {
+			return chain[len(chain)-1], nil
+		}
+		return chain[number.Uint64()], nil
+	}
+
+	for _, tt := range []struct {
+		count uint64
+		first uint64
+	}{
+		{count: 4, first: 6},
+		{count: 10, first: 0},
+		{count: 256, first: 0},
+	} {
+		headers, err := headerChain(context.Background(), headerByNumber, tt.count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := uint64(len(chain)) - tt.first; uint64(len(headers)) != want {
+			t.Errorf("number of headers mismatch for %d: have %d, want %d", tt.count, len(headers), want)
+			continue
+		}
+		if headers[0].Number.Uint64() != tt.first || headers[len(headers)-1] != chain[len(chain)-1] {
+			t.Errorf("window mismatch for %d: have %v to %v", tt.count, headers[0].Number, headers[len(headers)-1].Number)
+		}
+	}
+}
+
+func TestArtifactsDir(t *testing.T) {
+	os.Setenv(ArtifactsDirEnv, "/artifacts")
+	defer os.Unsetenv(ArtifactsDirEnv)
+
+	dir := ArtifactsDir("SFS-05: Byzantine Faulty 4 fullnodes, 1 silent")
+	if filepath.Dir(dir) != "/artifacts" {
+		t.Errorf("artifacts base dir mismatch: have %s, want %s", filepath.Dir(dir), "/artifacts")
+	}
+	if base := filepath.Base(dir); unsafeNameChars.MatchString(base) {
+		t.Errorf("unsafe artifacts dir name %s", base)
+	}
+}
@@ -21,30 +21,37 @@ package container
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"math/big"
 	"net"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
-	"github.com/docker/docker/client"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/phayes/freeport"
 
+	smilobft "go-smilo/src/blockchain/smilobft"
 	"go-smilo/src/blockchain/smilobft/accounts"
 	"go-smilo/src/blockchain/smilobft/accounts/keystore"
+	"go-smilo/src/blockchain/smilobft/p2p/discover"
 
 	smilocommon "go-smilo/src/blockchain/regression/src/common"
 	"go-smilo/src/blockchain/regression/src/genesis"
 )
 
 const (
+	// AccountPassword unlocks the accounts of the blockchain keystore
+	AccountPassword = ""
+
 	allocBalance     = "900000000000000000000000000000000000000000000"
 	veryLightScryptN = 2
 	veryLightScryptP = 1
-	defaultPassword  = ""
+
+	topologyCheckDelay     = time.Second
+	validatorSetCheckDelay = time.Second
+	rejoinTimeout          = 2 * time.Minute
 )
 
 type NodeIncubator interface {
@@ -53,12 +60,57 @@ type NodeIncubator interface {
 
 type Blockchain interface {
 	AddFullnodes(numOfFullnodes int) ([]Ethereum, error)
+	// RemoveFullnodes votes out the candidates, waits up to the timeout until
+	// the other fullnodes no longer list them and stops them.
 	RemoveFullnodes(candidates []Ethereum, t time.Duration) error
+	// WaitForValidatorSet waits until every fullnode lists the expected
+	// fullnodes at its latest block.
+	WaitForValidatorSet(expected []common.Address, t time.Duration) error
 	EnsureConsensusWorking(geths []Ethereum, t time.Duration) error
-	Start(bool) error
+	// Start starts the fullnodes and connects them as the topology. If it is
+	// nil the blockchain keeps the topology it was built with, by default a
+	// full mesh.
+	Start(Topology) error
+	// Rewire disconnects the links missing from the topology and connects
+	// the new ones.
+	Rewire(Topology) error
+	// WaitForTopology waits until the peers of every fullnode match the
+	// topology.
+	WaitForTopology(time.Duration) error
+	// RestartFullnode restarts the fullnode from its data dir and dials
+	// its peers of the topology again.
+	RestartFullnode(Ethereum) error
+	// Upgrade switches the fullnodes one by one to the image with the given
+	// tag, every fullnode is connected to its peers again before the next
+	// one is stopped.
+	Upgrade(fullnodes []Ethereum, tag string) error
 	Stop(bool) error
+	// Fullnodes returns the current fullnodes, the slice is not changed when
+	// fullnodes are added or removed later.
 	Fullnodes() []Ethereum
+	// Fullnode returns the fullnode with the given name. Fullnodes are named
+	// fullnode-<index> in the order they were created and keep their name
+	// while others are added or removed.
+	Fullnode(name string) (Ethereum, bool)
+	// AddObservers starts nodes which sync the chain from the fullnodes
+	// without mining, they are not counted as fullnodes.
+	AddObservers(num int, options ...Option) ([]Ethereum, error)
+	Observers() []Ethereum
+	// WaitForObserversCaughtUp waits until every observer has the head of
+	// the fullnodes at the time of the call.
+	WaitForObserversCaughtUp(t time.Duration) error
 	Finalize()
+	// CollectArtifacts saves the genesis file and the artifacts of every
+	// fullnode into dir, before the blockchain is stopped.
+	CollectArtifacts(dir string) error
+
+	// Accounts are the funded accounts generated for the fullnodes, the
+	// account of a fullnode is at its index.
+	Accounts() []accounts.Account
+	// Keystore holds the keys of the accounts, they are unlocked with
+	// AccountPassword.
+	Keystore() *keystore.KeyStore
+	GenesisFile() string
 }
 
 func GetGoSmiloImage() string {
@@ -68,19 +120,48 @@ func GetGoSmiloImage() string {
 func GetVaultImage() string {
 	return "quay.io/smilo/smilo-blackbox"
 }
+
+// DefaultOptions are the options shared by all fullnodes, they leave out the
+// image, the docker network and logging.
+func DefaultOptions() []Option {
+	return []Option{
+		DataDir("/data"),
+		WebSocket(),
+		WebSocketAddress("0.0.0.0"),
+		WebSocketAPI("personal,admin,db,eth,net,web3,miner,shh,txpool,debug,smilobft,sport"),
+		WebSocketOrigin("*"),
+		RPC(),
+		RPCAddress("0.0.0.0"),
+		RPCAPI("personal,admin,db,eth,debug,miner,net,shh,txpool,personal,web3,smilobft,sport"),
+		RPCOrigin("*"),
+		NAT("any"),
+		NoDiscover(),
+		//Testnet(),
+		Etherbase("1a9afb711302c5f83b5902843d1c007a1a137632"),
+		Mine(),
+		SyncMode("full"),
+		Unlock(0),
+		Password("password.txt"),
+	}
+}
+
 func NewBlockchain(network *DockerNetwork, numOfFullnodes int, options ...Option) (bc *blockchain, err error) {
+	return newBlockchain(network, numOfFullnodes, nil, options...)
+}
+
+func newBlockchain(network *DockerNetwork, numOfFullnodes int, genesisOptions []genesis.Option, options ...Option) (bc *blockchain, err error) {
 	if network == nil {
 		//log.Error("Docker network is required")
 		return nil, fmt.Errorf("Docker network is required")
 	}
 
-	bc = &blockchain{dockerNetwork: network, opts: options}
+	bc = &blockchain{dockerNetwork: network, opts: options, genesisOptions: genesisOptions}
 
 	var err1 error
-	bc.dockerClient, err1 = client.NewEnvClient()
+	bc.runtime, err1 = NewRuntime()
 	if err1 != nil {
-		//log.Error("Failed to connect to Docker daemon", "err", err)
-		return nil, fmt.Errorf("Failed to connect to Docker daemon %s", err1)
+		//log.Error("Failed to create container runtime", "err", err)
+		return nil, fmt.Errorf("Failed to create container runtime %s", err1)
 	}
 
 	bc.opts = append(bc.opts, DockerNetworkName(bc.dockerNetwork.Name()))
@@ -95,118 +176,80 @@ func NewBlockchain(network *DockerNetwork, numOfFullnodes int, options ...Option
 	return bc, nil
 }
 
-func NewDefaultBlockchain(network *DockerNetwork, numOfFullnodes int) (bc *blockchain, err error) {
-	return NewBlockchain(network,
-		numOfFullnodes,
-		ImageRepository(GetGoSmiloImage()),
-		ImageTag("latest"),
-		DataDir("/data"),
-		WebSocket(),
-		WebSocketAddress("0.0.0.0"),
-		WebSocketAPI("personal,admin,db,eth,net,web3,miner,shh,txpool,debug,smilobft,sport"),
-		WebSocketOrigin("*"),
-		RPC(),
-		RPCAddress("0.0.0.0"),
-		RPCAPI("personal,admin,db,eth,debug,miner,net,shh,txpool,personal,web3,smilobft,sport"),
-		RPCOrigin("*"),
-		NAT("any"),
-		NoDiscover(),
-		//Testnet(),
-		Etherbase("1a9afb711302c5f83b5902843d1c007a1a137632"),
-		Mine(),
-		SyncMode("full"),
-		Unlock(0),
-		Password("password.txt"),
-		Logging(true),
-		IsSmilo(true),
-	)
+// NewDefaultBlockchain creates numOfFullnodes fullnodes, the genesis options
+// override the defaults of the genesis block.
+func NewDefaultBlockchain(network *DockerNetwork, numOfFullnodes int, genesisOptions ...genesis.Option) (bc *blockchain, err error) {
+	options := append([]Option{ImageRepository(GetGoSmiloImage()), ImageTag("latest")}, DefaultOptions()...)
+	options = append(options, Logging(true), IsSmilo(true))
+	return newBlockchain(network, numOfFullnodes, genesisOptions, options...)
 }
 
-func NewDefaultBlockchainWithFaulty(network *DockerNetwork, numOfNormal int, numOfFaulty int) (bc *blockchain, err error) {
+// NewDefaultBlockchainWithFaulty creates numOfNormal fullnodes followed by a
+// faulty fullnode for every behaviour in faulty.
+func NewDefaultBlockchainWithFaulty(network *DockerNetwork, numOfNormal int, faulty []FaultyBehaviour, genesisOptions ...genesis.Option) (bc *blockchain, err error) {
 	if network == nil {
 		//log.Error("Docker network is required")
 		return nil, fmt.Errorf("Docker network is required")
 	}
 
-	commonOpts := [...]Option{
-		DockerNetworkName(network.Name()),
-		DataDir("/data"),
-		WebSocket(),
-		WebSocketAddress("0.0.0.0"),
-		WebSocketAPI("personal,admin,db,eth,net,web3,miner,shh,txpool,debug,smilobft,sport"),
-		WebSocketOrigin("*"),
-		RPC(),
-		RPCAddress("0.0.0.0"),
-		RPCAPI("personal,admin,db,eth,debug,miner,net,shh,txpool,personal,web3,smilobft,sport"),
-		RPCOrigin("*"),
-		NAT("any"),
-		NoDiscover(),
-		//Testnet(),
-		Etherbase("1a9afb711302c5f83b5902843d1c007a1a137632"),
-		Mine(),
-		SyncMode("full"),
-		Unlock(0),
-		Password("password.txt"),
-		Logging(false)}
-	normalOpts := make([]Option, len(commonOpts), len(commonOpts)+2)
-	copy(normalOpts, commonOpts[:])
-	normalOpts = append(normalOpts, ImageRepository(GetGoSmiloImage()), ImageTag("latest"))
-	faultyOpts := make([]Option, len(commonOpts), len(commonOpts)+3)
-	copy(faultyOpts, commonOpts[:])
-	faultyOpts = append(faultyOpts, ImageRepository(GetGoSmiloImage()), ImageTag("regression_test"), FaultyMode(1))
-
 	// New env client
-	bc = &blockchain{dockerNetwork: network}
+	bc = &blockchain{dockerNetwork: network, genesisOptions: genesisOptions}
 	var err1 error
-	bc.dockerClient, err1 = client.NewEnvClient()
+	bc.runtime, err1 = NewRuntime()
 	if err1 != nil {
-		//log.Error("Failed to connect to Docker daemon", "err", err)
-		return nil, fmt.Errorf("Failed to connect to Docker daemon %s", err1)
+		//log.Error("Failed to create container runtime", "err", err)
+		return nil, fmt.Errorf("Failed to create container runtime %s", err1)
 	}
 
-	totalNodes := numOfNormal + numOfFaulty
-
-	ips, err1 := bc.dockerNetwork.GetFreeIPAddrs(totalNodes)
-	if err1 != nil {
-		//log.Error("Failed to get free ip addresses", "err", err)
-		return nil, fmt.Errorf("Failed to get free ip addresses %s", err1)
+	commonOpts := append(DefaultOptions(), DockerNetworkName(network.Name()), Logging(false))
+	if err1 = bc.setupFaultyFullnodes(numOfNormal, faulty, commonOpts); err1 != nil {
+		return nil, err1
 	}
+	return bc, nil
+}
 
-	//Create accounts
-	bc.generateAccounts(totalNodes)
+// NewDefaultBlockchainWithTags creates a fullnode for every tag, running the
+// image with that tag. Fullnodes added later run the latest image.
+func NewDefaultBlockchainWithTags(network *DockerNetwork, tags []string, genesisOptions ...genesis.Option) (bc *blockchain, err error) {
+	if network == nil {
+		//log.Error("Docker network is required")
+		return nil, fmt.Errorf("Docker network is required")
+	}
 
-	keys, _, addrs := smilocommon.GenerateKeys(totalNodes)
-	bc.setupGenesis(addrs)
-	// Create normal fullnodes
-	bc.opts = normalOpts
-	if err1 = bc.setupFullnodes(ips[:numOfNormal], keys[:numOfNormal], 0, bc.opts...); err1 != nil {
-		//log.Error("Error setting up normal fullnodes")
-		return nil, fmt.Errorf("Error setting up normal fullnodes %s", err1)
+	bc = &blockchain{dockerNetwork: network, genesisOptions: genesisOptions}
+	var err1 error
+	bc.runtime, err1 = NewRuntime()
+	if err1 != nil {
+		//log.Error("Failed to create container runtime", "err", err)
+		return nil, fmt.Errorf("Failed to create container runtime %s", err1)
 	}
-	// Create faulty fullnodes
-	bc.opts = faultyOpts
-	if err1 = bc.setupFullnodes(ips[numOfNormal:], keys[numOfNormal:], numOfNormal, bc.opts...); err1 != nil {
-		//log.Error("Error setting up faulty fullnodes")
-		return nil, fmt.Errorf("Error setting up faulty fullnodes %s", err1)
+
+	commonOpts := append(DefaultOptions(), DockerNetworkName(network.Name()), Logging(false))
+	if err1 = bc.setupTaggedFullnodes(tags, commonOpts); err1 != nil {
+		return nil, err1
 	}
 	return bc, nil
 }
 
 func NewSmiloBlockchain(network *DockerNetwork, ctn VaultNetwork, options ...Option) (bc *blockchain, err error) {
+	return newSmiloBlockchain(network, ctn, nil, options...)
+}
+
+func newSmiloBlockchain(network *DockerNetwork, ctn VaultNetwork, genesisOptions []genesis.Option, options ...Option) (bc *blockchain, err error) {
 	if network == nil {
 		//log.Error("Docker network is required")
 		return nil, fmt.Errorf("Docker network is required")
 	}
 
-	bc = &blockchain{dockerNetwork: network, opts: options, isSmilo: true, vaultNetwork: ctn}
+	bc = &blockchain{dockerNetwork: network, opts: options, isSmilo: true, vaultNetwork: ctn, genesisOptions: genesisOptions}
 	bc.opts = append(bc.opts, IsSmilo(true))
 	bc.opts = append(bc.opts, NoUSB())
 
 	var err1 error
-	bc.dockerClient, err1 = client.NewEnvClient()
+	bc.runtime, err1 = NewRuntime()
 	if err1 != nil {
-		//log.Error("Failed to connect to Docker daemon", "err", err1)
-		return nil, fmt.Errorf("Failed to connect to Docker daemon %s", err1)
+		//log.Error("Failed to create container runtime", "err", err1)
+		return nil, fmt.Errorf("Failed to create container runtime %s", err1)
 	}
 
 	bc.opts = append(bc.opts, DockerNetworkName(bc.dockerNetwork.Name()))
@@ -221,101 +264,35 @@ func NewSmiloBlockchain(network *DockerNetwork, ctn VaultNetwork, options ...Opt
 	return bc, nil
 }
 
-func NewDefaultSmiloBlockchain(network *DockerNetwork, ctn VaultNetwork) (bc *blockchain, err error) {
-	return NewSmiloBlockchain(network,
-		ctn,
-		ImageRepository(GetGoSmiloImage()),
-		ImageTag("latest"),
-		DataDir("/data"),
-		WebSocket(),
-		WebSocketAddress("0.0.0.0"),
-		WebSocketAPI("personal,admin,db,eth,net,web3,miner,shh,txpool,debug,smilobft,sport"),
-		WebSocketOrigin("*"),
-		RPC(),
-		RPCAddress("0.0.0.0"),
-		RPCAPI("personal,admin,db,eth,debug,miner,net,shh,txpool,personal,web3,smilobft,sport"),
-		RPCOrigin("*"),
-		NAT("any"),
-		NoDiscover(),
-		//Testnet(),
-		Etherbase("1a9afb711302c5f83b5902843d1c007a1a137632"),
-		Mine(),
-		SyncMode("full"),
-		Unlock(0),
-		Password("password.txt"),
-		Logging(false),
-		IsSmilo(true),
-	)
+// NewDefaultSmiloBlockchain creates a fullnode for every vault of ctn, the
+// genesis options override the defaults of the genesis block.
+func NewDefaultSmiloBlockchain(network *DockerNetwork, ctn VaultNetwork, genesisOptions ...genesis.Option) (bc *blockchain, err error) {
+	options := append([]Option{ImageRepository(GetGoSmiloImage()), ImageTag("latest")}, DefaultOptions()...)
+	options = append(options, Logging(false), IsSmilo(true))
+	return newSmiloBlockchain(network, ctn, genesisOptions, options...)
 }
 
-func NewDefaultSmiloBlockchainWithFaulty(network *DockerNetwork, ctn VaultNetwork, numOfNormal int, numOfFaulty int) (bc *blockchain, err error) {
+// NewDefaultSmiloBlockchainWithFaulty creates numOfNormal fullnodes followed
+// by a faulty fullnode for every behaviour in faulty, paired with the vaults
+// of ctn.
+func NewDefaultSmiloBlockchainWithFaulty(network *DockerNetwork, ctn VaultNetwork, numOfNormal int, faulty []FaultyBehaviour, genesisOptions ...genesis.Option) (bc *blockchain, err error) {
 	if network == nil {
 		//log.Error("Docker network is required")
 		return nil, fmt.Errorf("Docker network is required")
 	}
 
-	commonOpts := [...]Option{
-		DockerNetworkName(network.Name()),
-		DataDir("/data"),
-		WebSocket(),
-		WebSocketAddress("0.0.0.0"),
-		WebSocketAPI("personal,admin,db,eth,net,web3,miner,shh,txpool,debug,smilobft,sport"),
-		WebSocketOrigin("*"),
-		RPC(),
-		RPCAddress("0.0.0.0"),
-		RPCAPI("personal,admin,db,eth,debug,miner,net,shh,txpool,personal,web3,smilobft,sport"),
-		RPCOrigin("*"),
-		NAT("any"),
-		NoDiscover(),
-		//Testnet(),
-		Etherbase("1a9afb711302c5f83b5902843d1c007a1a137632"),
-		Mine(),
-		SyncMode("full"),
-		Unlock(0),
-		Password("password.txt"),
-		Logging(false),
-		IsSmilo(true),
-	}
-	normalOpts := make([]Option, len(commonOpts), len(commonOpts)+2)
-	copy(normalOpts, commonOpts[:])
-	normalOpts = append(normalOpts, ImageRepository(GetGoSmiloImage()), ImageTag("latest"))
-	faultyOpts := make([]Option, len(commonOpts), len(commonOpts)+3)
-	copy(faultyOpts, commonOpts[:])
-	faultyOpts = append(faultyOpts, ImageRepository(GetGoSmiloImage()), ImageTag("regression_test"), FaultyMode(1))
-
 	// New env client
-	bc = &blockchain{dockerNetwork: network, isSmilo: true, vaultNetwork: ctn}
+	bc = &blockchain{dockerNetwork: network, isSmilo: true, vaultNetwork: ctn, genesisOptions: genesisOptions}
 	var err1 error
-	bc.dockerClient, err1 = client.NewEnvClient()
+	bc.runtime, err1 = NewRuntime()
 	if err1 != nil {
-		//log.Error("Failed to connect to Docker daemon", "err", err1)
-		return nil, fmt.Errorf("Failed to connect to Docker daemon %s", err1)
+		//log.Error("Failed to create container runtime", "err", err1)
+		return nil, fmt.Errorf("Failed to create container runtime %s", err1)
 	}
 
-	totalNodes := numOfNormal + numOfFaulty
-
-	ips, err1 := bc.dockerNetwork.GetFreeIPAddrs(totalNodes)
-	if err1 != nil {
-		//log.Error("Failed to get free ip addresses", "err", err1)
-		return nil, fmt.Errorf("Failed to get free ip addresses %s", err1)
-	}
-
-	//Create accounts
-	bc.generateAccounts(totalNodes)
-
-	keys, _, addrs := smilocommon.GenerateKeys(totalNodes)
-	bc.setupGenesis(addrs)
-	// Create normal fullnodes
-	bc.opts = normalOpts
-	if err1 = bc.setupFullnodes(ips[:numOfNormal], keys[:numOfNormal], 0, bc.opts...); err1 != nil {
-		//log.Error("Error setting up normal fullnodes")
-		return nil, fmt.Errorf("Error setting up normal fullnodes %s", err1)
-	}
-	// Create faulty fullnodes
-	bc.opts = faultyOpts
-	if err1 = bc.setupFullnodes(ips[numOfNormal:], keys[numOfNormal:], numOfNormal, bc.opts...); err1 != nil {
-		//log.Error("Error setting up faulty fullnodes")
-		return nil, fmt.Errorf("Error setting up faulty fullnodes %s", err1)
+	commonOpts := append(DefaultOptions(), DockerNetworkName(network.Name()), Logging(false), IsSmilo(true))
+	if err1 = bc.setupFaultyFullnodes(numOfNormal, faulty, commonOpts); err1 != nil {
+		return nil, err1
 	}
 	return bc, nil
 }
@@ -323,38 +300,57 @@ func NewDefaultSmiloBlockchainWithFaulty(network *DockerNetwork, ctn VaultNetwor
 // ----------------------------------------------------------------------------
 
 type blockchain struct {
-	dockerClient  *client.Client
+	runtime       Runtime
 	dockerNetwork *DockerNetwork
 	genesisFile   string
 	isSmilo       bool
-	fullnodes     []Ethereum
+	members       membership
+	observers     membership
 	opts          []Option
 	vaultNetwork  VaultNetwork
 	accounts      []accounts.Account
+	keystore      *keystore.KeyStore
 	keystorePath  string
+	// genesisOptions override the defaults of the generated genesis
+	genesisOptions []genesis.Option
+
+	// mutex serialises adding and removing fullnodes and guards the
+	// accounts and the topology, restarted fullnodes hold it for reading
+	// until they rejoined
+	mutex    sync.RWMutex
+	topology Topology
 }
 
 func (bc *blockchain) AddFullnodes(numOfFullnodes int) ([]Ethereum, error) {
-	// TODO: need a lock
-	lastLen := len(bc.fullnodes)
-	bc.addFullnodes(numOfFullnodes)
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
 
-	newFullnodes := bc.fullnodes[lastLen:]
+	lastLen := bc.members.len()
+	bc.generateAccounts(numOfFullnodes)
+	if err := bc.addFullnodes(numOfFullnodes); err != nil {
+		return nil, err
+	}
+
+	fullnodes := bc.members.snapshot()
+	newFullnodes := fullnodes[lastLen:]
 	if err := bc.start(newFullnodes); err != nil {
 		return nil, err
 	}
 
 	// propose new fullnodes as fullnode in consensus
-	for _, v := range bc.fullnodes[:lastLen] {
-		istClient := v.NewClient()
+	deadline := time.Now().Add(rejoinTimeout)
+	for _, v := range fullnodes[:lastLen] {
 		for _, newV := range newFullnodes {
-			if err := istClient.ProposeFullnode(context.Background(), newV.Address(), true); err != nil {
+			if err := vote(v, newV.Address(), true, deadline); err != nil {
 				return nil, err
 			}
 		}
 	}
 
-	if err := bc.connectAll(true); err != nil {
+	if err := relink(fullnodes[:lastLen], bc.topology, fullnodes, bc.topology); err != nil {
+		return nil, err
+	}
+	if err := bc.connectObservers(); err != nil {
 		return nil, err
 	}
 	return newFullnodes, nil
@@ -382,52 +378,360 @@ func (bc *blockchain) EnsureConsensusWorking(geths []Ethereum, t time.Duration)
 }
 
 func (bc *blockchain) RemoveFullnodes(candidates []Ethereum, processingTime time.Duration) error {
-	var newFullnodes []Ethereum
+	bc.mutex.Lock()
+	fullnodes := bc.members.snapshot()
+	bc.mutex.Unlock()
 
-	for _, v := range bc.fullnodes {
-		istClient := v.NewClient()
-		isFound := false
+	deadline := time.Now().Add(processingTime)
+	for _, v := range fullnodes {
 		for _, c := range candidates {
-			if err := istClient.ProposeFullnode(context.Background(), c.Address(), false); err != nil {
+			if err := vote(v, c.Address(), false, deadline); err != nil {
 				return err
 			}
-			if v.ContainerID() == c.ContainerID() {
-				isFound = true
+		}
+	}
+
+	removed := make(map[common.Address]bool)
+	for _, c := range candidates {
+		removed[c.Address()] = true
+	}
+	// The lock is released while the votes are processed, so fullnodes can
+	// be restarted, added or removed meanwhile
+	geth, have, err := waitForFullnodes(func() []Ethereum {
+		var remaining []Ethereum
+		for _, v := range bc.members.snapshot() {
+			if !removed[v.Address()] {
+				remaining = append(remaining, v)
 			}
 		}
-		if !isFound {
-			newFullnodes = append(newFullnodes, v)
+		return remaining
+	}, time.Until(deadline), func(have []common.Address) bool {
+		for _, addr := range have {
+			if removed[addr] {
+				return false
+			}
 		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if geth != nil {
+		return fmt.Errorf("fullnodes of %s still list the candidates: have %v", geth.Name(), have)
 	}
 
-	// FIXME: It is not good way to wait fullnode vote out candidates
-	<-time.After(processingTime)
-	bc.fullnodes = newFullnodes
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	before := bc.members.snapshot()
+	bc.members.remove(candidates)
+	if err := bc.stop(candidates, false); err != nil {
+		return err
+	}
+	return relink(before, bc.topology, bc.members.snapshot(), bc.topology)
+}
 
-	return bc.stop(candidates, false)
+// vote proposes to add or remove the address on the fullnode, it is retried
+// until the deadline while the fullnode is restarted.
+func vote(geth Ethereum, address common.Address, auth bool, deadline time.Time) error {
+	for {
+		err := errors.New("failed to retrieve client")
+		if cli := geth.NewClient(); cli != nil {
+			err = cli.ProposeFullnode(context.Background(), address, auth)
+			cli.Close()
+		}
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		<-time.After(validatorSetCheckDelay)
+	}
 }
 
-func (bc *blockchain) Start(strong bool) error {
-	if err := bc.start(bc.fullnodes); err != nil {
+func (bc *blockchain) WaitForValidatorSet(expected []common.Address, timeout time.Duration) error {
+	return waitForValidatorSet(bc.members.snapshot, expected, timeout)
+}
+
+func waitForValidatorSet(fullnodes func() []Ethereum, expected []common.Address, timeout time.Duration) error {
+	want := make(map[common.Address]bool)
+	for _, addr := range expected {
+		want[addr] = true
+	}
+
+	geth, have, err := waitForFullnodes(fullnodes, timeout, func(have []common.Address) bool {
+		if len(have) != len(want) {
+			return false
+		}
+		for _, addr := range have {
+			if !want[addr] {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
 		return err
 	}
-	return bc.connectAll(strong)
+	if geth != nil {
+		return fmt.Errorf("fullnodes of %s do not match: have %v, want %v", geth.Name(), have, expected)
+	}
+	return nil
+}
+
+// waitForFullnodes waits until match accepts the validator set of every
+// fullnode, the fullnodes are looked up again on every check. On timeout it
+// returns the first fullnode whose set does not match along with its set, or
+// the error of the last check. Fullnodes which do not answer are checked again
+// until the timeout, they may be restarting.
+func waitForFullnodes(fullnodes func() []Ethereum, timeout time.Duration, match func([]common.Address) bool) (Ethereum, []common.Address, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		geth, have, err := checkValidatorSet(fullnodes(), match)
+		if err == nil && geth == nil {
+			return nil, nil, nil
+		}
+		if time.Now().After(deadline) {
+			return geth, have, err
+		}
+		<-time.After(validatorSetCheckDelay)
+	}
+}
+
+// checkValidatorSet returns the first fullnode whose validator set is not
+// accepted by match along with its set, or nil.
+func checkValidatorSet(fullnodes []Ethereum, match func([]common.Address) bool) (Ethereum, []common.Address, error) {
+	for _, geth := range fullnodes {
+		cli := geth.NewClient()
+		if cli == nil {
+			return nil, nil, errors.New("failed to retrieve client")
+		}
+		have, err := cli.GetFullnodes(context.Background(), nil)
+		cli.Close()
+		if err != nil && err != smilobft.NotFound {
+			return nil, nil, err
+		}
+
+		if !match(have) {
+			return geth, have, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+func (bc *blockchain) Start(t Topology) error {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	if t == nil {
+		t = bc.topology
+	}
+	if t == nil {
+		t = FullMesh()
+	}
+	fullnodes := bc.members.snapshot()
+	if err := t.Validate(len(fullnodes)); err != nil {
+		return err
+	}
+	bc.topology = t
+
+	if err := bc.start(fullnodes); err != nil {
+		return err
+	}
+	if err := bc.connect(t); err != nil {
+		return err
+	}
+
+	// Observers stopped with the blockchain are started again
+	if err := bc.start(bc.observers.snapshot()); err != nil {
+		return err
+	}
+	return bc.connectObservers()
+}
+
+func (bc *blockchain) Rewire(t Topology) error {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	fullnodes := bc.members.snapshot()
+	if err := relink(fullnodes, bc.topology, fullnodes, t); err != nil {
+		return err
+	}
+	bc.topology = t
+	return nil
+}
+
+func (bc *blockchain) RestartFullnode(geth Ethereum) error {
+	return bc.rejoin(geth, geth.Restart)
+}
+
+func (bc *blockchain) Upgrade(fullnodes []Ethereum, tag string) error {
+	for _, geth := range fullnodes {
+		geth := geth
+		if err := bc.rejoin(geth, func() error { return geth.Upgrade(tag) }); err != nil {
+			return fmt.Errorf("failed to upgrade fullnode %s to %s: %v", geth.Name(), tag, err)
+		}
+	}
+	return nil
+}
+
+// rejoin restarts the fullnode with restart, dials its peers of the topology
+// again and waits until all its neighbours are connected. Fullnodes may be
+// restarted concurrently with each other and while fullnodes are added or
+// removed, the peers are looked up once the fullnode is up again.
+func (bc *blockchain) rejoin(geth Ethereum, restart func() error) error {
+	if position(bc.members.snapshot(), geth) < 0 {
+		return errors.New("fullnode is not part of the blockchain")
+	}
+
+	if err := restart(); err != nil {
+		return err
+	}
+
+	// The positions of the topology shift when fullnodes are added or
+	// removed, so they are taken from one snapshot, the lock is not held
+	// while dialing and waiting to let membership changes go on
+	bc.mutex.RLock()
+	fullnodes := bc.members.snapshot()
+	t := bc.topology
+	bc.mutex.RUnlock()
+	idx := position(fullnodes, geth)
+	if idx < 0 {
+		return errors.New("fullnode was removed from the blockchain")
+	}
+
+	// The other side redials its static peers by itself
+	if t == nil {
+		t = FullMesh()
+	}
+	n := len(fullnodes)
+	for _, p := range t.Peers(idx, n) {
+		if err := geth.AddPeer(fullnodes[p].NodeAddress()); err != nil {
+			return err
+		}
+	}
+	return geth.WaitForPeersConnected(len(Neighbours(t, idx, n)), rejoinTimeout)
+}
+
+func (bc *blockchain) WaitForTopology(timeout time.Duration) error {
+	bc.mutex.RLock()
+	fullnodes := bc.members.snapshot()
+	t := bc.topology
+	bc.mutex.RUnlock()
+	if t == nil {
+		return errors.New("blockchain is not started")
+	}
+
+	ids := make([]string, len(fullnodes))
+	for i, v := range fullnodes {
+		node, err := discover.ParseNode(v.NodeAddress())
+		if err != nil {
+			return err
+		}
+		ids[i] = node.ID.String()
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		idx, err := checkPeers(fullnodes, t, ids)
+		if err != nil {
+			return err
+		}
+		if idx < 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("peers of fullnode %d do not match the topology", idx)
+		}
+		<-time.After(topologyCheckDelay)
+	}
+}
+
+// checkPeers returns the index of the first fullnode whose peers do not match
+// the topology, or -1. Peers which are not fullnodes, like observers, are
+// ignored.
+func checkPeers(fullnodes []Ethereum, t Topology, ids []string) (int, error) {
+	n := len(fullnodes)
+	isFullnode := make(map[string]bool)
+	for _, id := range ids {
+		isFullnode[id] = true
+	}
+	for idx, v := range fullnodes {
+		cli := v.NewClient()
+		if cli == nil {
+			return 0, errors.New("failed to retrieve client")
+		}
+		peers, err := cli.AdminPeers(context.Background())
+		cli.Close()
+		if err != nil {
+			return 0, err
+		}
+
+		connected := make(map[string]bool)
+		for _, peer := range peers {
+			if isFullnode[peer.ID] {
+				connected[peer.ID] = true
+			}
+		}
+		expected := Neighbours(t, idx, n)
+		if len(connected) != len(expected) {
+			return idx, nil
+		}
+		for _, p := range expected {
+			if !connected[ids[p]] {
+				return idx, nil
+			}
+		}
+	}
+	return -1, nil
 }
 
 func (bc *blockchain) Stop(force bool) error {
-	if err := bc.stop(bc.fullnodes, force); err != nil {
+	if err := bc.stop(bc.observers.snapshot(), force); err != nil {
+		return err
+	}
+	if err := bc.stop(bc.members.snapshot(), force); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// Finalize removes the genesis file, the keystore and the data dirs of the
+// fullnodes and observers.
 func (bc *blockchain) Finalize() {
-	os.RemoveAll(filepath.Dir(bc.genesisFile))
+	if bc.genesisFile != "" {
+		os.RemoveAll(filepath.Dir(bc.genesisFile))
+	}
+	if bc.keystorePath != "" {
+		os.RemoveAll(bc.keystorePath)
+	}
+	for _, geth := range append(bc.members.snapshot(), bc.observers.snapshot()...) {
+		if eth, ok := geth.(*ethereum); ok && eth.dataDir != "" {
+			if err := os.RemoveAll(eth.dataDir); err != nil {
+				eth.logger().Warn("Failed to remove data dir", "dir", eth.dataDir, "err", err)
+			}
+		}
+	}
 }
 
 func (bc *blockchain) Fullnodes() []Ethereum {
-	return bc.fullnodes
+	return bc.members.snapshot()
+}
+
+func (bc *blockchain) Fullnode(name string) (Ethereum, bool) {
+	return bc.members.byName(name)
+}
+
+func (bc *blockchain) Accounts() []accounts.Account {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	return append([]accounts.Account{}, bc.accounts...)
+}
+
+func (bc *blockchain) Keystore() *keystore.KeyStore {
+	return bc.keystore
+}
+
+func (bc *blockchain) GenesisFile() string {
+	return bc.genesisFile
 }
 
 func (bc *blockchain) CreateNodes(num int, options ...Option) (nodes []Ethereum, err error) {
@@ -447,18 +751,18 @@ func (bc *blockchain) CreateNodes(num int, options ...Option) (nodes []Ethereum,
 			return nil, err
 		}
 		opts = append(opts, HostDataDir(dataDir))
-		opts = append(opts, HostWebSocketPort(freeport.GetPort()))
+		opts = append(opts, HostWebSocketPort(freePort()))
 		opts = append(opts, HostIP(ips[i]))
 		opts = append(opts, DockerNetworkName(bc.dockerNetwork.Name()))
 
 		geth := NewEthereum(
-			bc.dockerClient,
+			bc.runtime,
 			opts...,
 		)
 
 		err = geth.Init(bc.genesisFile)
 		if err != nil {
-			log.Error("Failed to init genesis", "file", bc.genesisFile, "err", err)
+			geth.logger().Error("Failed to init genesis", "file", bc.genesisFile, "err", err)
 			return nil, err
 		}
 
@@ -477,25 +781,63 @@ func (bc *blockchain) addFullnodes(numOfFullnodes int) error {
 	}
 	keys, _, addrs := smilocommon.GenerateKeys(numOfFullnodes)
 	bc.setupGenesis(addrs)
-	if err = bc.setupFullnodes(ips, keys, 0, bc.opts...); err != nil {
+	if err = bc.setupFullnodes(ips, keys, bc.members.next(), bc.opts...); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (bc *blockchain) connectAll(strong bool) error {
-	for idx, v := range bc.fullnodes {
-		if strong {
-			for _, vv := range bc.fullnodes {
-				if v.ContainerID() != vv.ContainerID() {
-					if err := v.AddPeer(vv.NodeAddress()); err != nil {
-						return err
-					}
-				}
+func (bc *blockchain) connect(t Topology) error {
+	if t == nil {
+		t = FullMesh()
+	}
+	fullnodes := bc.members.snapshot()
+	for idx, v := range fullnodes {
+		for _, p := range t.Peers(idx, len(fullnodes)) {
+			if err := v.AddPeer(fullnodes[p].NodeAddress()); err != nil {
+				return err
 			}
-		} else {
-			nextFullnode := bc.fullnodes[(idx+1)%len(bc.fullnodes)]
-			if err := v.AddPeer(nextFullnode.NodeAddress()); err != nil {
+		}
+	}
+	return nil
+}
+
+// relink moves the links between the fullnodes from topology from over before
+// to topology to over after. Topologies index the fullnodes by position, which
+// shifts as fullnodes are added or removed, so the links are compared by node.
+func relink(before []Ethereum, from Topology, after []Ethereum, to Topology) error {
+	if to == nil {
+		to = FullMesh()
+	}
+	if err := to.Validate(len(after)); err != nil {
+		return err
+	}
+
+	keep := links(after, to)
+	if from != nil {
+		nodes := make(map[string]Ethereum)
+		for _, v := range after {
+			nodes[v.NodeAddress()] = v
+		}
+		for link := range links(before, from) {
+			// Removed fullnodes are stopped, their links are gone
+			a, b := nodes[link[0]], nodes[link[1]]
+			if keep[link] || a == nil || b == nil {
+				continue
+			}
+			// Both sides drop the link, or the one which dialled redials it
+			if err := a.RemovePeer(b.NodeAddress()); err != nil {
+				return err
+			}
+			if err := b.RemovePeer(a.NodeAddress()); err != nil {
+				return err
+			}
+		}
+	}
+
+	for idx, v := range after {
+		for _, p := range to.Peers(idx, len(after)) {
+			if err := v.AddPeer(after[p].NodeAddress()); err != nil {
 				return err
 			}
 		}
@@ -503,21 +845,47 @@ func (bc *blockchain) connectAll(strong bool) error {
 	return nil
 }
 
+// links returns the links of the topology over the fullnodes as pairs of
+// enode URLs, the smaller one first.
+func links(fullnodes []Ethereum, t Topology) map[[2]string]bool {
+	result := make(map[[2]string]bool)
+	for idx, v := range fullnodes {
+		for _, p := range t.Peers(idx, len(fullnodes)) {
+			a, b := v.NodeAddress(), fullnodes[p].NodeAddress()
+			if a > b {
+				a, b = b, a
+			}
+			result[[2]string{a, b}] = true
+		}
+	}
+	return result
+}
+
 func (bc *blockchain) generateAccounts(num int) {
 	// Create keystore object
-	d, err := ioutil.TempDir("", "sport-keystore")
-	if err != nil {
-		log.Error("Failed to create temp folder for keystore", "err", err)
-		return
+	if bc.keystorePath == "" {
+		d, err := smilocommon.GenerateKeystoreDir()
+		if err != nil {
+			log.Error("Failed to create temp folder for keystore", "err", err)
+			return
+		}
+		bc.keystorePath = d
+	}
+	if bc.keystore == nil {
+		bc.keystore = keystore.NewKeyStore(bc.keystorePath, veryLightScryptN, veryLightScryptP)
 	}
-	ks := keystore.NewKeyStore(d, veryLightScryptN, veryLightScryptP)
-	bc.keystorePath = d
+	ks := bc.keystore
 
-	// Create accounts
+	// Create accounts from the seeded keys
 	for i := 0; i < num; i++ {
-		a, e := ks.NewAccount(defaultPassword)
+		key, e := smilocommon.GenerateKey()
 		if e != nil {
-			log.Error("Failed to create account", "err", err)
+			log.Error("Failed to generate account key", "err", e)
+			return
+		}
+		a, e := ks.ImportECDSA(key, AccountPassword)
+		if e != nil {
+			log.Error("Failed to create account", "err", e)
 			return
 		}
 		bc.accounts = append(bc.accounts, a)
@@ -532,10 +900,11 @@ func (bc *blockchain) setupGenesis(addrs []common.Address) {
 		for _, acc := range bc.accounts {
 			allocAddrs = append(allocAddrs, acc.Address)
 		}
-		bc.genesisFile = genesis.NewFile(bc.isSmilo,
+		opts := []genesis.Option{
 			genesis.Fullnodes(addrs...),
 			genesis.Alloc(allocAddrs, balance),
-		)
+		}
+		bc.genesisFile = genesis.NewFile(bc.isSmilo, append(opts, bc.genesisOptions...)...)
 	}
 }
 
@@ -552,9 +921,10 @@ func (bc *blockchain) setupFullnodes(ips []net.IP, keys []*ecdsa.PrivateKey, off
 			return err
 		}
 		opts = append(opts, HostDataDir(dataDir))
-		opts = append(opts, HostWebSocketPort(freeport.GetPort()))
+		opts = append(opts, HostWebSocketPort(freePort()))
 		opts = append(opts, Key(keys[i]))
 		opts = append(opts, HostIP(ips[i]))
+		opts = append(opts, NodeName(memberName(i+offset)))
 
 		accounts := bc.accounts[i+offset : i+offset+1]
 		var addrs []common.Address
@@ -565,29 +935,101 @@ func (bc *blockchain) setupFullnodes(ips []net.IP, keys []*ecdsa.PrivateKey, off
 
 		// Add PRIVATE_CONFIG for smilo
 		if bc.isSmilo {
-			ct := bc.vaultNetwork.GetVault(i)
+			ct := bc.vaultNetwork.GetVault((i + offset) % bc.vaultNetwork.NumOfVaults())
 			env := fmt.Sprintf("PRIVATE_CONFIG=%s", ct.ConfigPath())
 			opts = append(opts, DockerEnv([]string{env}))
 			opts = append(opts, DockerBinds(ct.Binds()))
 		}
 
 		geth := NewEthereum(
-			bc.dockerClient,
+			bc.runtime,
 			opts...,
 		)
 
 		// Copy keystore to datadir
-		smilocommon.GeneratePasswordFile(dataDir, geth.password, defaultPassword)
+		smilocommon.GeneratePasswordFile(dataDir, geth.password, AccountPassword)
 		smilocommon.CopyKeystore(dataDir, accounts)
 
 		err = geth.Init(bc.genesisFile)
 		if err != nil {
-			log.Error("Failed to init genesis", "file", bc.genesisFile, "err", err)
+			geth.logger().Error("Failed to init genesis", "file", bc.genesisFile, "err", err)
 			return err
 		}
 
-		bc.fullnodes = append(bc.fullnodes, geth)
+		bc.members.add(geth)
+	}
+	return nil
+}
+
+// setupFaultyFullnodes creates numOfNormal fullnodes with the latest image and
+// a fullnode with the FaultyTag image for every behaviour in faulty. Fullnodes
+// added later are honest.
+func (bc *blockchain) setupFaultyFullnodes(numOfNormal int, faulty []FaultyBehaviour, commonOpts []Option) error {
+	for i, b := range faulty {
+		if err := b.Validate(); err != nil {
+			return fmt.Errorf("Invalid faulty fullnode %d %s", i, err)
+		}
+	}
+
+	normalOpts := make([]Option, len(commonOpts), len(commonOpts)+2)
+	copy(normalOpts, commonOpts)
+	normalOpts = append(normalOpts, ImageRepository(GetGoSmiloImage()), ImageTag("latest"))
+
+	totalNodes := numOfNormal + len(faulty)
+
+	ips, err := bc.dockerNetwork.GetFreeIPAddrs(totalNodes)
+	if err != nil {
+		//log.Error("Failed to get free ip addresses", "err", err)
+		return fmt.Errorf("Failed to get free ip addresses %s", err)
+	}
+
+	//Create accounts
+	bc.generateAccounts(totalNodes)
+
+	keys, _, addrs := smilocommon.GenerateKeys(totalNodes)
+	bc.setupGenesis(addrs)
+	// Create normal fullnodes
+	bc.opts = normalOpts
+	if err = bc.setupFullnodes(ips[:numOfNormal], keys[:numOfNormal], 0, bc.opts...); err != nil {
+		//log.Error("Error setting up normal fullnodes")
+		return fmt.Errorf("Error setting up normal fullnodes %s", err)
+	}
+	// Create faulty fullnodes
+	for i, b := range faulty {
+		idx := numOfNormal + i
+		faultyOpts := make([]Option, len(commonOpts), len(commonOpts)+3)
+		copy(faultyOpts, commonOpts)
+		faultyOpts = append(faultyOpts, ImageRepository(GetGoSmiloImage()), ImageTag(FaultyTag), Faulty(b))
+		if err = bc.setupFullnodes(ips[idx:idx+1], keys[idx:idx+1], idx, faultyOpts...); err != nil {
+			//log.Error("Error setting up faulty fullnodes")
+			return fmt.Errorf("Error setting up faulty fullnode %d (%s) %s", idx, b, err)
+		}
+	}
+	return nil
+}
+
+// setupTaggedFullnodes creates a fullnode running the image with the tag for
+// every tag in tags. Fullnodes added later run the latest image.
+func (bc *blockchain) setupTaggedFullnodes(tags []string, commonOpts []Option) error {
+	ips, err := bc.dockerNetwork.GetFreeIPAddrs(len(tags))
+	if err != nil {
+		return fmt.Errorf("Failed to get free ip addresses %s", err)
+	}
+
+	bc.generateAccounts(len(tags))
+
+	keys, _, addrs := smilocommon.GenerateKeys(len(tags))
+	bc.setupGenesis(addrs)
+	for i, tag := range tags {
+		opts := make([]Option, len(commonOpts), len(commonOpts)+2)
+		copy(opts, commonOpts)
+		opts = append(opts, ImageRepository(GetGoSmiloImage()), ImageTag(tag))
+		if err = bc.setupFullnodes(ips[i:i+1], keys[i:i+1], i, opts...); err != nil {
+			return fmt.Errorf("Error setting up fullnode %d (%s) %s", i, tag, err)
+		}
 	}
+
+	bc.opts = append(commonOpts[:len(commonOpts):len(commonOpts)], ImageRepository(GetGoSmiloImage()), ImageTag("latest"))
 	return nil
 }
 
@@ -616,6 +1058,7 @@ type VaultNetwork interface {
 	Finalize()
 	NumOfVaults() int
 	GetVault(int) Vault
+	CollectArtifacts(dir string) error
 }
 
 func NewVaultNetwork(network *DockerNetwork, numOfFullnodes int, options ...VaultOption) (ctn *vaultNetwork, err error) {
@@ -626,10 +1069,10 @@ func NewVaultNetwork(network *DockerNetwork, numOfFullnodes int, options ...Vaul
 	ctn = &vaultNetwork{dockerNetwork: network, opts: options}
 
 	var err1 error
-	ctn.dockerClient, err1 = client.NewEnvClient()
+	ctn.runtime, err1 = NewRuntime()
 	if err1 != nil {
-		log.Error("Failed to connect to Docker daemon", "err", err1)
-		return nil, fmt.Errorf("Failed to connect to Docker daemon %s", err1)
+		log.Error("Failed to create container runtime", "err", err1)
+		return nil, fmt.Errorf("Failed to create container runtime %s", err1)
 	}
 
 	ctn.opts = append(ctn.opts, CTDockerNetworkName(ctn.dockerNetwork.Name()))
@@ -660,7 +1103,7 @@ func (ctn *vaultNetwork) setupVaults(numOfFullnodes int) (error) {
 		opts := append(ctn.opts, CTHost(ips[i], ports[i]))
 		othernodes := ctn.getOtherNodes(ips, ports, i)
 		opts = append(opts, CTOtherNodes(othernodes))
-		ct := NewVault(ctn.dockerClient, opts...)
+		ct := NewVault(ctn.runtime, opts...)
 		// Generate keys
 		if _, err := ct.GenerateKey(); err != nil {
 			return err
@@ -715,9 +1158,16 @@ func (ctn *vaultNetwork) getFreeHosts(num int) ([]net.IP, []int) {
 		log.Error("Cannot get free ip", "err", err)
 		return nil, nil
 	}
+	// Processes all listen on the loopback interface, the vaults reach each
+	// other by their ports
+	if _, ok := ctn.runtime.(*processRuntime); ok {
+		for i := range ips {
+			ips[i] = net.ParseIP(loopbackIP)
+		}
+	}
 	var ports []int
 	for i := 0; i < num; i++ {
-		ports = append(ports, freeport.GetPort())
+		ports = append(ports, freePort())
 	}
 	return ips, ports
 }
@@ -734,7 +1184,7 @@ func (ctn *vaultNetwork) getOtherNodes(ips []net.IP, ports []int, idx int) []str
 }
 
 type vaultNetwork struct {
-	dockerClient  *client.Client
+	runtime       Runtime
 	dockerNetwork *DockerNetwork
 	opts          []VaultOption
 	vaults        []Vault
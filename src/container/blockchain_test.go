@@ -19,15 +19,27 @@
 package container
 
 import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"go-smilo/src/blockchain/smilobft/core"
 )
 
 func TestEthereumBlockchain(t *testing.T) {
+	requireDocker(t)
 
 	dockerNetwork, err := NewDockerNetwork()
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	defer dockerNetwork.Remove()
 
@@ -52,7 +64,7 @@ func TestEthereumBlockchain(t *testing.T) {
 	}
 	defer chain.Finalize()
 
-	err = chain.Start(true)
+	err = chain.Start(FullMesh())
 	if err != nil {
 		t.Error(err)
 	}
@@ -64,3 +76,693 @@ func TestEthereumBlockchain(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func newTestBlockchain(t *testing.T, numOfFullnodes int) (*blockchain, *fakeRuntime) {
+	rt := newFakeRuntime()
+	bc := &blockchain{
+		runtime:       rt,
+		dockerNetwork: newTestNetwork(t, "172.19.0.0/16"),
+		opts: []Option{
+			ImageRepository("fake"),
+			ImageTag("latest"),
+			DataDir("/data"),
+			WebSocket(),
+			NoDiscover(),
+			Password("password.txt"),
+		},
+	}
+
+	bc.generateAccounts(numOfFullnodes)
+	if err := bc.addFullnodes(numOfFullnodes); err != nil {
+		t.Fatal(err)
+	}
+	return bc, rt
+}
+
+func finalizeTestBlockchain(bc *blockchain) {
+	for _, v := range bc.members.snapshot() {
+		os.RemoveAll(v.(*ethereum).dataDir)
+	}
+	os.RemoveAll(bc.keystorePath)
+	bc.Finalize()
+}
+
+func containsPeer(peers []string, peer string) bool {
+	for _, p := range peers {
+		if p == peer {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSetupFullnodes(t *testing.T) {
+	bc, _ := newTestBlockchain(t, 3)
+	defer finalizeTestBlockchain(bc)
+
+	if len(bc.Fullnodes()) != 3 {
+		t.Fatalf("fullnode count mismatch: have %d, want %d", len(bc.Fullnodes()), 3)
+	}
+
+	raw, err := ioutil.ReadFile(bc.genesisFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var g core.Genesis
+	if err := json.Unmarshal(raw, &g); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range bc.Fullnodes() {
+		// Every fullnode owns the account matching its index
+		if len(v.Accounts()) != 1 || v.Accounts()[0] != bc.accounts[i].Address {
+			t.Errorf("fullnode %d account mismatch: have %v, want %v", i, v.Accounts(), bc.accounts[i].Address)
+		}
+		if _, ok := g.Alloc[v.Address()]; !ok {
+			t.Errorf("fullnode %d address %v is not allocated in genesis", i, v.Address())
+		}
+		if _, ok := g.Alloc[bc.accounts[i].Address]; !ok {
+			t.Errorf("account %v is not allocated in genesis", bc.accounts[i].Address)
+		}
+		if _, err := os.Stat(filepath.Join(v.(*ethereum).dataDir, "password.txt")); err != nil {
+			t.Errorf("fullnode %d has no password file: %v", i, err)
+		}
+	}
+}
+
+func TestStartFullMesh(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 4)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(FullMesh()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	for i, v := range bc.Fullnodes() {
+		peers := rt.backend(v.IP()).Peers()
+		if len(peers) != 3 {
+			t.Errorf("fullnode %d peer count mismatch: have %d, want %d", i, len(peers), 3)
+		}
+		for j, vv := range bc.Fullnodes() {
+			if i != j && !containsPeer(peers, vv.NodeAddress()) {
+				t.Errorf("fullnode %d is not connected to fullnode %d", i, j)
+			}
+		}
+	}
+}
+
+func TestStartRing(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 4)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(Ring()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	fullnodes := bc.Fullnodes()
+	for i, v := range fullnodes {
+		peers := rt.backend(v.IP()).Peers()
+		next := fullnodes[(i+1)%len(fullnodes)]
+		if len(peers) != 1 || peers[0] != next.NodeAddress() {
+			t.Errorf("fullnode %d peers mismatch: have %v, want %v", i, peers, next.NodeAddress())
+		}
+	}
+}
+
+func TestAddFullnodes(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 2)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(FullMesh()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	newFullnodes, err := bc.AddFullnodes(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newFullnodes) != 1 || len(bc.Fullnodes()) != 3 {
+		t.Fatalf("fullnode count mismatch: have %d new of %d, want 1 new of 3", len(newFullnodes), len(bc.Fullnodes()))
+	}
+
+	newFullnode := newFullnodes[0]
+	if len(bc.accounts) != 3 || newFullnode.Accounts()[0] != bc.accounts[2].Address {
+		t.Errorf("new fullnode account mismatch: have %v, want %v", newFullnode.Accounts(), bc.accounts[len(bc.accounts)-1].Address)
+	}
+	if newFullnode.IP() != "172.19.0.4" {
+		t.Errorf("new fullnode ip mismatch: have %v, want %v", newFullnode.IP(), "172.19.0.4")
+	}
+
+	for i, v := range bc.Fullnodes()[:2] {
+		if auth, ok := rt.backend(v.IP()).Proposals()[newFullnode.Address()]; !ok || !auth {
+			t.Errorf("fullnode %d did not propose the new fullnode", i)
+		}
+	}
+	peers := rt.backend(newFullnode.IP()).Peers()
+	for i, v := range bc.Fullnodes()[:2] {
+		if !containsPeer(peers, v.NodeAddress()) {
+			t.Errorf("new fullnode is not connected to fullnode %d", i)
+		}
+	}
+}
+
+func TestRemoveFullnodes(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 4)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(FullMesh()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	setGenesisFullnodes(bc, rt)
+
+	candidate := bc.Fullnodes()[3]
+	if err := bc.RemoveFullnodes([]Ethereum{candidate}, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bc.Fullnodes()) != 3 {
+		t.Fatalf("fullnode count mismatch: have %d, want %d", len(bc.Fullnodes()), 3)
+	}
+	for i, v := range bc.Fullnodes() {
+		if v.ContainerID() == candidate.ContainerID() {
+			t.Errorf("fullnode %d is the removed candidate", i)
+		}
+		if auth, ok := rt.backend(v.IP()).Proposals()[candidate.Address()]; !ok || auth {
+			t.Errorf("fullnode %d did not vote out the candidate", i)
+		}
+	}
+	if candidate.Running() {
+		t.Error("removed candidate is still running")
+	}
+}
+
+func TestMembershipKeepsTopology(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 5)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(Ring()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	setGenesisFullnodes(bc, rt)
+
+	// The fullnodes after the removed one move up in the ring
+	if err := bc.RemoveFullnodes([]Ethereum{bc.Fullnodes()[2]}, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.WaitForTopology(10 * time.Second); err != nil {
+		t.Errorf("topology mismatch after removal: %v", err)
+	}
+
+	// The link closing the ring moves to the added fullnode
+	fullnodes := bc.Fullnodes()
+	if _, err := bc.AddFullnodes(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.WaitForTopology(10 * time.Second); err != nil {
+		t.Errorf("topology mismatch after adding: %v", err)
+	}
+	if peers := rt.backend(fullnodes[3].IP()).Peers(); containsPeer(peers, fullnodes[0].NodeAddress()) {
+		t.Errorf("last fullnode still dials the first one: %v", peers)
+	}
+}
+
+func TestConcurrentMembershipChanges(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 5)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(Ring()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	setGenesisFullnodes(bc, rt)
+	fullnodes := bc.Fullnodes()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		errs <- bc.RestartFullnode(fullnodes[0])
+	}()
+	go func() {
+		defer wg.Done()
+		errs <- bc.RemoveFullnodes([]Ethereum{fullnodes[2]}, 10*time.Second)
+	}()
+	go func() {
+		defer wg.Done()
+		_, err := bc.AddFullnodes(1)
+		errs <- err
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	if len(bc.Fullnodes()) != 5 {
+		t.Errorf("fullnode count mismatch: have %d, want %d", len(bc.Fullnodes()), 5)
+	}
+	if err := bc.WaitForTopology(10 * time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMembershipChangesWhileRejoining(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 3)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(Ring()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	setGenesisFullnodes(bc, rt)
+	fullnodes := bc.Fullnodes()
+
+	// The restarted fullnode waits for its neighbour until it is back
+	neighbour := rt.backend(fullnodes[1].IP())
+	neighbour.setRunning(false)
+	b := rt.backend(fullnodes[0].IP())
+	b.mutex.Lock()
+	b.peers = nil
+	b.mutex.Unlock()
+	rejoined := make(chan error, 1)
+	go func() {
+		rejoined <- bc.RestartFullnode(fullnodes[0])
+	}()
+	for len(b.Peers()) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	added := make(chan error, 1)
+	go func() {
+		_, err := bc.AddFullnodes(1)
+		added <- err
+	}()
+	select {
+	case err := <-added:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("adding a fullnode is blocked by a rejoining fullnode")
+	}
+
+	neighbour.setRunning(true)
+	if err := <-rejoined; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWaitForTopology(t *testing.T) {
+	bc, _ := newTestBlockchain(t, 4)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(Star(0)); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	if err := bc.WaitForTopology(10 * time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRewire(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 4)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(Line()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	if err := bc.Rewire(Star(0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.WaitForTopology(10 * time.Second); err != nil {
+		t.Error(err)
+	}
+
+	fullnodes := bc.Fullnodes()
+	if peers := rt.backend(fullnodes[1].IP()).Peers(); containsPeer(peers, fullnodes[2].NodeAddress()) {
+		t.Errorf("fullnode 1 still dials fullnode 2: %v", peers)
+	}
+	if err := bc.Rewire(RandomRegular(5, 1)); err == nil {
+		t.Error("expected an error for an impossible topology")
+	}
+}
+
+func TestRestartFullnode(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 3)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(Ring()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	fullnodes := bc.Fullnodes()
+	geth := fullnodes[0]
+	enode, id := geth.NodeAddress(), geth.ContainerID()
+
+	if err := geth.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.container(id); err == nil {
+		t.Errorf("container %s of the killed fullnode was not removed", id)
+	}
+	// A killed node forgets the peers it dialled
+	b := rt.backend(geth.IP())
+	b.mutex.Lock()
+	b.peers = nil
+	b.mutex.Unlock()
+
+	if err := bc.RestartFullnode(geth); err != nil {
+		t.Fatal(err)
+	}
+	if geth.NodeAddress() != enode {
+		t.Errorf("enode mismatch after restart: have %s, want %s", geth.NodeAddress(), enode)
+	}
+	if geth.ContainerID() == id {
+		t.Errorf("fullnode was not restarted in a new container")
+	}
+	if peers := b.Peers(); len(peers) != 1 || peers[0] != fullnodes[1].NodeAddress() {
+		t.Errorf("peers mismatch after restart: have %v, want %v", peers, fullnodes[1].NodeAddress())
+	}
+
+	// A graceful restart of a running fullnode keeps its enode too
+	if err := geth.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	if geth.NodeAddress() != enode {
+		t.Errorf("enode mismatch after second restart: have %s, want %s", geth.NodeAddress(), enode)
+	}
+
+	if err := bc.RestartFullnode(NewEthereum(rt)); err == nil {
+		t.Error("expected an error for a fullnode of another blockchain")
+	}
+}
+
+func TestPauseFullnode(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 1)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(nil); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	geth := bc.Fullnodes()[0]
+	if err := geth.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := rt.container(geth.ContainerID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.paused {
+		t.Error("container was not paused")
+	}
+	if err := geth.Pause(); err == nil {
+		t.Error("expected an error for pausing a paused fullnode")
+	}
+
+	if err := geth.Unpause(); err != nil {
+		t.Fatal(err)
+	}
+	if c.paused {
+		t.Error("container was not unpaused")
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 3)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(FullMesh()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	fullnodes := bc.Fullnodes()
+	enode := fullnodes[0].NodeAddress()
+
+	checkImages := func(want ...string) {
+		for i, v := range fullnodes {
+			c, err := rt.container(v.ContainerID())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.Image() != want[i] || c.spec.Image != want[i] {
+				t.Errorf("fullnode %d image mismatch: have %s in container %s, want %s", i, v.Image(), c.spec.Image, want[i])
+			}
+		}
+	}
+
+	if err := bc.Upgrade(fullnodes[:2], "next"); err != nil {
+		t.Fatal(err)
+	}
+	checkImages("fake:next", "fake:next", "fake:latest")
+	if fullnodes[0].NodeAddress() != enode {
+		t.Errorf("enode mismatch after upgrade: have %s, want %s", fullnodes[0].NodeAddress(), enode)
+	}
+	if err := bc.WaitForTopology(10 * time.Second); err != nil {
+		t.Error(err)
+	}
+
+	// Roll back
+	if err := bc.Upgrade(fullnodes[:2], "latest"); err != nil {
+		t.Fatal(err)
+	}
+	checkImages("fake:latest", "fake:latest", "fake:latest")
+}
+
+// setGenesisFullnodes makes every backend list all the fullnodes.
+func setGenesisFullnodes(bc *blockchain, rt *fakeRuntime) []common.Address {
+	var addrs []common.Address
+	for _, v := range bc.Fullnodes() {
+		addrs = append(addrs, v.Address())
+	}
+	for _, v := range bc.Fullnodes() {
+		rt.backend(v.IP()).SetFullnodes(append([]common.Address{}, addrs...))
+	}
+	return addrs
+}
+
+func TestWaitForValidatorSet(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 3)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(FullMesh()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	addrs := setGenesisFullnodes(bc, rt)
+	if err := bc.WaitForValidatorSet(addrs, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fullnode 2 is behind and still lists fullnode 0
+	for _, v := range bc.Fullnodes()[:2] {
+		rt.backend(v.IP()).SetFullnodes(addrs[1:])
+	}
+	if err := bc.WaitForValidatorSet(addrs[1:], 0); err == nil {
+		t.Error("expected an error for a fullnode with another validator set")
+	}
+}
+
+func TestGovernance(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 2)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(FullMesh()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	addrs := setGenesisFullnodes(bc, rt)
+	candidate := common.HexToAddress("0x1a9afb711302c5f83b5902843d1c007a1a137632")
+
+	cli := bc.Fullnodes()[0].NewClient()
+	if cli == nil {
+		t.Fatal("failed to retrieve client")
+	}
+	defer cli.Close()
+
+	ctx := context.Background()
+	if err := cli.ProposeFullnode(ctx, candidate, true); err != nil {
+		t.Fatal(err)
+	}
+	candidates, err := cli.Candidates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth, ok := candidates[candidate]; !ok || !auth {
+		t.Errorf("candidates mismatch: have %v, want %v", candidates, candidate)
+	}
+
+	snapshot, err := cli.GetSnapshot(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Fullnodes) != len(addrs)+1 || !snapshot.Tally[candidate].Authorize {
+		t.Errorf("snapshot mismatch: have %+v", snapshot)
+	}
+	fullnodes, err := cli.GetFullnodesAtHash(ctx, snapshot.Hash)
+	if err != nil || len(fullnodes) != len(addrs)+1 {
+		t.Errorf("fullnodes at hash mismatch: have %v, err %v", fullnodes, err)
+	}
+
+	if err := cli.DiscardProposal(ctx, candidate); err != nil {
+		t.Fatal(err)
+	}
+	if candidates, err := cli.Candidates(ctx); err != nil || len(candidates) != 0 {
+		t.Errorf("candidates after discard mismatch: have %v, err %v", candidates, err)
+	}
+}
+
+func TestStableNames(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 3)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(FullMesh()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	setGenesisFullnodes(bc, rt)
+	removed := bc.Fullnodes()[1]
+	if err := bc.RemoveFullnodes([]Ethereum{removed}, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	added, err := bc.AddFullnodes(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, v := range bc.Fullnodes() {
+		names = append(names, v.Name())
+	}
+	if strings.Join(names, ",") != "fullnode-0,fullnode-2,fullnode-3" {
+		t.Errorf("names mismatch: have %v", names)
+	}
+	if v, ok := bc.Fullnode("fullnode-3"); !ok || v != added[0] {
+		t.Errorf("fullnode-3 is not the added fullnode: %v", v)
+	}
+	if _, ok := bc.Fullnode("fullnode-1"); ok {
+		t.Error("removed fullnode is still found by name")
+	}
+	// The added fullnode does not reuse the account of another fullnode
+	if accounts := added[0].Accounts(); len(accounts) != 1 || accounts[0] != bc.accounts[3].Address {
+		t.Errorf("account mismatch: have %v, want %v", accounts, bc.accounts[3].Address)
+	}
+}
+
+func TestAddObservers(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 3)
+	defer finalizeTestBlockchain(bc)
+	bc.opts = append(bc.opts, Mine(), Unlock(0))
+
+	if err := bc.Start(FullMesh()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	observers, err := bc.AddObservers(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	observer := observers[0]
+
+	if observer.Name() != "observer-0" {
+		t.Errorf("name mismatch: have %s, want %s", observer.Name(), "observer-0")
+	}
+	if len(bc.Fullnodes()) != 3 || len(bc.Observers()) != 1 {
+		t.Errorf("members mismatch: have %d fullnodes and %d observers", len(bc.Fullnodes()), len(bc.Observers()))
+	}
+	c, err := rt.container(observer.ContainerID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, flag := range c.spec.Cmd {
+		if flag == "--mine" || flag == "--unlock" {
+			t.Errorf("observer has flag %s", flag)
+		}
+	}
+	for _, v := range bc.Fullnodes() {
+		if !containsPeer(rt.backend(observer.IP()).Peers(), v.NodeAddress()) {
+			t.Errorf("observer did not dial %s", v.Name())
+		}
+	}
+	// Observers are not part of the topology
+	if err := bc.WaitForTopology(5 * time.Second); err != nil {
+		t.Error(err)
+	}
+
+	for _, v := range bc.Fullnodes() {
+		rt.backend(v.IP()).Mine(3)
+	}
+	if err := bc.WaitForObserversCaughtUp(2 * time.Second); err == nil {
+		t.Error("observer caught up without the blocks")
+	}
+	go func() {
+		<-time.After(time.Second)
+		rt.backend(observer.IP()).Mine(3)
+	}()
+	if err := bc.WaitForObserversCaughtUp(10 * time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddObserversWhileRemovingFullnodes(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 4)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(FullMesh()); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	setGenesisFullnodes(bc, rt)
+	fullnodes := bc.Fullnodes()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, err := bc.AddObservers(2)
+		errs <- err
+	}()
+	go func() {
+		defer wg.Done()
+		errs <- bc.RemoveFullnodes([]Ethereum{fullnodes[3]}, 10*time.Second)
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	if len(bc.Fullnodes()) != 3 || len(bc.Observers()) != 2 {
+		t.Errorf("members mismatch: have %d fullnodes and %d observers", len(bc.Fullnodes()), len(bc.Observers()))
+	}
+	if err := bc.WaitForObserversCaughtUp(10 * time.Second); err != nil {
+		t.Error(err)
+	}
+}
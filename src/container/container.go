@@ -19,11 +19,7 @@
 package container
 
 import (
-	"net"
-	"net/url"
-	"os"
-
-	"github.com/docker/docker/client"
+	"strconv"
 )
 
 func (eth *ethereum) Image() string {
@@ -37,27 +33,26 @@ func (eth *ethereum) ContainerID() string {
 	return eth.containerID
 }
 
+func (eth *ethereum) IP() string {
+	return eth.ip
+}
+
 func (eth *ethereum) Host() string {
-	var host string
-	daemonHost := os.Getenv("DOCKER_HOST")
-	if daemonHost == "" {
-		daemonHost = client.DefaultDockerHost
-	}
-	url, err := url.Parse(daemonHost)
+	return eth.runtime.Host()
+}
+
+func atoi(s string) int {
+	i, err := strconv.Atoi(s)
 	if err != nil {
-		log.Error("Failed to parse daemon host", "host", daemonHost, "err", err)
-		return host
+		log.Error("Failed to parse number", "value", s, "err", err)
 	}
+	return i
+}
 
-	if url.Scheme == "unix" {
-		host = "localhost"
-	} else {
-		host, _, err = net.SplitHostPort(url.Host)
-		if err != nil {
-			log.Error("Failed to split host and port", "url", url.Host, "err", err)
-			return ""
-		}
+// shortID returns the short form of a container id used by docker ps.
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
 	}
-
-	return host
+	return id
 }
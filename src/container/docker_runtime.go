@@ -0,0 +1,279 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	docker "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+)
+
+const (
+	execRetryCount = 100
+	execRetryDelay = 100 * time.Millisecond
+)
+
+type dockerRuntime struct {
+	client *docker.Client
+}
+
+func NewDockerRuntime(c *docker.Client) Runtime {
+	return &dockerRuntime{
+		client: c,
+	}
+}
+
+func NewEnvDockerRuntime() (Runtime, error) {
+	c, err := docker.NewEnvClient()
+	if err != nil {
+		return nil, err
+	}
+	return NewDockerRuntime(c), nil
+}
+
+func (r *dockerRuntime) Host() string {
+	var host string
+	daemonHost := os.Getenv("DOCKER_HOST")
+	if daemonHost == "" {
+		daemonHost = docker.DefaultDockerHost
+	}
+	url, err := url.Parse(daemonHost)
+	if err != nil {
+		log.Error("Failed to parse daemon host", "host", daemonHost, "err", err)
+		return host
+	}
+
+	if url.Scheme == "unix" {
+		host = "localhost"
+	} else {
+		host, _, err = net.SplitHostPort(url.Host)
+		if err != nil {
+			log.Error("Failed to split host and port", "url", url.Host, "err", err)
+			return ""
+		}
+	}
+
+	return host
+}
+
+func (r *dockerRuntime) EnsureImage(image string, out io.Writer) error {
+	filters := filters.NewArgs()
+	filters.Add("reference", image)
+
+	images, err := r.client.ImageList(context.Background(), types.ImageListOptions{
+		Filters: filters,
+	})
+
+	if len(images) == 0 || err != nil {
+		reader, err := r.client.ImagePull(context.Background(), image, types.ImagePullOptions{})
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		io.Copy(out, reader)
+	}
+	return nil
+}
+
+func (r *dockerRuntime) Create(spec *Spec) (string, error) {
+	exposedPorts := nat.PortSet{}
+	portBindings := nat.PortMap{}
+	for _, p := range spec.Ports {
+		port := nat.Port(fmt.Sprintf("%d", p.ContainerPort))
+		exposedPorts[port] = struct{}{}
+		if p.HostPort != 0 {
+			portBindings[port] = []nat.PortBinding{
+				{
+					HostIP:   "0.0.0.0",
+					HostPort: fmt.Sprintf("%d", p.HostPort),
+				},
+			}
+		}
+	}
+
+	var networkingConfig *network.NetworkingConfig
+	if spec.IP != "" && spec.NetworkName != "" {
+		endpointsConfig := make(map[string]*network.EndpointSettings)
+		endpointsConfig[spec.NetworkName] = &network.EndpointSettings{
+			IPAMConfig: &network.EndpointIPAMConfig{
+				IPv4Address: spec.IP,
+			},
+		}
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: endpointsConfig,
+		}
+	}
+
+	resp, err := r.client.ContainerCreate(context.Background(),
+		&container.Config{
+			Hostname:     spec.Hostname,
+			Image:        spec.Image,
+			Cmd:          spec.Cmd,
+			Env:          spec.Env,
+			WorkingDir:   spec.WorkingDir,
+			ExposedPorts: exposedPorts,
+			Labels:       resourceLabels(),
+		},
+		&container.HostConfig{
+			Binds:        spec.Binds,
+			PortBindings: portBindings,
+		}, networkingConfig, "")
+	if err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+func (r *dockerRuntime) Start(id string) error {
+	return r.client.ContainerStart(context.Background(), id, types.ContainerStartOptions{})
+}
+
+func (r *dockerRuntime) Stop(id string, timeout time.Duration) error {
+	return r.client.ContainerStop(context.Background(), id, &timeout)
+}
+
+func (r *dockerRuntime) Kill(id string, signal string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	return r.client.ContainerKill(ctx, id, signal)
+}
+
+func (r *dockerRuntime) Pause(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	return r.client.ContainerPause(ctx, id)
+}
+
+func (r *dockerRuntime) Unpause(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	return r.client.ContainerUnpause(ctx, id)
+}
+
+func (r *dockerRuntime) Remove(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	return r.client.ContainerRemove(ctx, id, types.ContainerRemoveOptions{Force: true})
+}
+
+func (r *dockerRuntime) Inspect(id string) (*Status, error) {
+	containerJSON, err := r.client.ContainerInspect(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{}
+	if containerJSON.ContainerJSONBase != nil && containerJSON.State != nil {
+		status.Running = containerJSON.State.Running
+		status.ExitCode = containerJSON.State.ExitCode
+	}
+	if containerJSON.NetworkSettings != nil {
+		status.IP = containerJSON.NetworkSettings.IPAddress
+		for _, endpoint := range containerJSON.NetworkSettings.Networks {
+			if endpoint != nil && endpoint.IPAddress != "" {
+				status.IP = endpoint.IPAddress
+				break
+			}
+		}
+	}
+	return status, nil
+}
+
+func (r *dockerRuntime) Wait(id string, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	waitC, errC := r.client.ContainerWait(ctx, id, container.WaitConditionNotRunning)
+	select {
+	case status := <-waitC:
+		return int(status.StatusCode), nil
+	case err := <-errC:
+		return -1, err
+	}
+}
+
+func (r *dockerRuntime) Logs(ctx context.Context, id string, follow bool, stdout, stderr io.Writer) error {
+	readCloser, err := r.client.ContainerLogs(ctx, id,
+		types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Follow: follow})
+	if err != nil {
+		return err
+	}
+	defer readCloser.Close()
+
+	_, err = stdcopy.StdCopy(stdout, stderr, readCloser)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+func (r *dockerRuntime) Exec(id string, cmd ...string) error {
+	resp, err := r.client.ContainerExecCreate(context.Background(), id, types.ExecConfig{
+		Cmd:        cmd,
+		Privileged: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.client.ContainerExecStart(context.Background(), resp.ID, types.ExecStartCheck{Detach: true})
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < execRetryCount; i++ {
+		inspect, err := r.client.ContainerExecInspect(context.Background(), resp.ID)
+		if err != nil {
+			return err
+		}
+		if !inspect.Running {
+			if inspect.ExitCode != 0 {
+				return fmt.Errorf("command %v exited with code %d", cmd, inspect.ExitCode)
+			}
+			return nil
+		}
+		<-time.After(execRetryDelay)
+	}
+	return fmt.Errorf("command %v did not finish", cmd)
+}
+
+func (r *dockerRuntime) Attach(id string, stdin []byte) error {
+	hiresp, err := r.client.ContainerAttach(context.Background(), id, types.ContainerAttachOptions{Stream: true, Stdin: true})
+	if err != nil {
+		return err
+	}
+	defer hiresp.Close()
+
+	_, err = hiresp.Conn.Write(stdin)
+	return err
+}
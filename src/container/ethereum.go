@@ -34,13 +34,8 @@ import (
 
 	"github.com/ethereum/go-ethereum/crypto"
 
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/filters"
-	"github.com/docker/docker/api/types/network"
-	docker "github.com/docker/docker/client"
-	"github.com/docker/go-connections/nat"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/inconshreveable/log15"
 
 	"go-smilo/src/blockchain/smilobft/cmd/utils"
 	ethtypes "go-smilo/src/blockchain/smilobft/core/types"
@@ -54,11 +49,14 @@ import (
 const (
 	healthCheckRetryCount = 60
 	healthCheckRetryDelay = 2 * time.Second
+	stopTimeout           = 30 * time.Second
+	initTimeout           = 15 * time.Second
 )
 
 var (
-	ErrNoBlock = errors.New("no block generated")
-	ErrTimeout = errors.New("timeout")
+	ErrNoBlock        = errors.New("no block generated")
+	ErrTimeout        = errors.New("timeout")
+	ErrNoSubscription = errors.New("no head subscription")
 )
 
 type Ethereum interface {
@@ -66,25 +64,62 @@ type Ethereum interface {
 	Start() error
 	Stop() error
 
+	// Kill kills the fullnode with SIGKILL, without a graceful shutdown, and
+	// removes its container. The data dir is kept for Start or Restart.
+	Kill() error
+	// Restart stops the fullnode if it is running and starts it again with
+	// the same data dir, IP and node key, so its enode does not change.
+	Restart() error
+	// Pause freezes the fullnode, it keeps its connections open but does
+	// not answer until Unpause.
+	Pause() error
+	Unpause() error
+	// Upgrade restarts the fullnode from its data dir with the image of the
+	// given tag.
+	Upgrade(tag string) error
+	// Image is the image the fullnode runs
+	Image() string
+	// Running tells whether the container of the fullnode is running
+	Running() bool
+
 	NodeAddress() string
 	Address() common.Address
 
 	ContainerID() string
 	Host() string
+	IP() string
 	NewClient() client.Client
 	ConsensusMonitor(err chan<- error, quit chan struct{})
 
 	WaitForProposed(expectedAddress common.Address, t time.Duration) error
-	WaitForPeersConnected(int) error
+	// The waits below time out after an hour unless a timeout is given, they
+	// return a *WaitError if they do not complete.
+	WaitForPeersConnected(int, ...time.Duration) error
 	WaitForBlocks(int, ...time.Duration) error
-	WaitForBlockHeight(int) error
+	WaitForBlockHeight(int, ...time.Duration) error
 	// Want for block for no more than the given number during the given time duration
 	WaitForNoBlocks(int, time.Duration) error
 
 	// Wait for settling balances for the given accounts
 	WaitForBalances([]common.Address, ...time.Duration) error
 
+	// Context aware versions of the above, the deadline of the context
+	// replaces the timeout
+	WaitForPeersConnectedContext(context.Context, int) error
+	WaitForBlocksContext(context.Context, int) error
+	WaitForBlockHeightContext(context.Context, int) error
+	WaitForNoBlocksContext(context.Context, int) error
+	WaitForBalancesContext(context.Context, []common.Address) error
+
+	// FollowHeads calls fn with the current head and every new one until fn
+	// returns true or an error, or the context is done.
+	FollowHeads(context.Context, func(*ethtypes.Header) (bool, error)) error
+
 	AddPeer(string) error
+	RemovePeer(string) error
+
+	// Exec runs the given command inside the node's container
+	Exec(cmd ...string) error
 
 	StartMining() error
 	StopMining() error
@@ -93,36 +128,35 @@ type Ethereum interface {
 
 	DockerEnv() []string
 	DockerBinds() []string
+
+	// Name is the name of the node in log lines
+	Name() string
+	// Logs writes the logs of the node's container to stdout and stderr
+	Logs(ctx context.Context, follow bool, stdout, stderr io.Writer) error
+
+	// CollectArtifacts saves the logs, node info, peers, header chain and
+	// data dir of the fullnode into the given directory
+	CollectArtifacts(dir string) error
 }
 
-func NewEthereum(c *docker.Client, options ...Option) *ethereum {
+func NewEthereum(rt Runtime, options ...Option) *ethereum {
 	eth := &ethereum{
-		dockerClient: c,
+		runtime: rt,
 	}
 
 	for _, opt := range options {
 		opt(eth)
 	}
 
-	fmt.Println("NewEthereum, image, ", eth.Image())
-	filters := filters.NewArgs()
-	filters.Add("reference", eth.Image())
+	eth.logger().Info("Creating fullnode", "image", eth.Image())
 
-	images, err := c.ImageList(context.Background(), types.ImageListOptions{
-		Filters: filters,
-	})
-
-	if len(images) == 0 || err != nil {
-		out, err := eth.dockerClient.ImagePull(context.Background(), eth.Image(), types.ImagePullOptions{})
-		if err != nil {
-			log.Error("Failed to pull image", "image", eth.Image(), "err", err)
-			return nil
-		}
-		if eth.logging {
-			io.Copy(os.Stdout, out)
-		} else {
-			io.Copy(ioutil.Discard, out)
-		}
+	var out io.Writer = ioutil.Discard
+	if eth.logging {
+		out = os.Stdout
+	}
+	if err := eth.runtime.EnsureImage(eth.Image(), out); err != nil {
+		eth.logger().Error("Failed to pull image", "image", eth.Image(), "err", err)
+		return nil
 	}
 
 	return eth
@@ -130,6 +164,7 @@ func NewEthereum(c *docker.Client, options ...Option) *ethereum {
 
 type ethereum struct {
 	ok          bool
+	name        string
 	flags       []string
 	dataDir     string
 	ip          string
@@ -138,7 +173,6 @@ type ethereum struct {
 	wsPort      string
 	hostName    string
 	containerID string
-	node        *discover.Node
 	accounts    []common.Address
 	password    string
 
@@ -151,9 +185,15 @@ type ethereum struct {
 	imageTag          string
 	dockerNetworkName string
 
-	key          *ecdsa.PrivateKey
-	logging      bool
-	dockerClient *docker.Client
+	key     *ecdsa.PrivateKey
+	logging bool
+	runtime Runtime
+	paused  bool
+
+	// nodeMutex guards node, it is set again on every start while other
+	// fullnodes may be dialling it
+	nodeMutex sync.RWMutex
+	node      *discover.Node
 }
 
 var errCancelled = errors.New("build cancelled")
@@ -170,60 +210,48 @@ func (eth *ethereum) Init(genesisFile string) error {
 		binds = append(binds, eth.dataDir+":"+utils.DataDirFlag.Value.Value)
 	}
 
-	resp, err := eth.dockerClient.ContainerCreate(context.Background(),
-		&container.Config{
-			Image: eth.Image(),
-			Cmd: []string{
-				"init",
-				"--" + utils.DataDirFlag.Name,
-				utils.DataDirFlag.Value.Value,
-				filepath.Join("/", genesis.FileName),
-			},
+	id, err := eth.runtime.Create(&Spec{
+		Image: eth.Image(),
+		Cmd: []string{
+			"init",
+			"--" + utils.DataDirFlag.Name,
+			utils.DataDirFlag.Value.Value,
+			filepath.Join("/", genesis.FileName),
 		},
-		&container.HostConfig{
-			Binds: binds,
-		}, nil, "")
+		Binds: binds,
+	})
 	if err != nil {
-		log.Error("Failed to create container", "err", err)
+		eth.logger().Error("Failed to create container", "err", err)
 		return err
 	}
 
-	id := resp.ID
-
-	if err := eth.dockerClient.ContainerStart(context.Background(), id, types.ContainerStartOptions{}); err != nil {
-		log.Error("Failed to start container", "err", err)
+	if err := eth.runtime.Start(id); err != nil {
+		eth.logger().Error("Failed to start container", "err", err)
+		if rerr := eth.runtime.Remove(id); rerr != nil {
+			eth.logger().Error("Failed to remove GETH container", "err", rerr)
+		}
+		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
-
 	if eth.logging {
-		eth.showLog(context.Background())
+		eth.showLog(context.Background(), id)
 	}
 
-	waitC, _ := eth.dockerClient.ContainerWait(ctx, id, container.WaitConditionNotRunning)
-	if status := <-waitC; status.StatusCode != 0 {
-		//close(finished)
-		err1 := fmt.Errorf("a non-zero code from GETH ContainerWait: %d", status.StatusCode)
+	if code, err := eth.runtime.Wait(id, initTimeout); err != nil || code != 0 {
+		err1 := fmt.Errorf("a non-zero code from GETH ContainerWait: %d, %v", code, err)
 		logCancellationError(err1.Error())
 		return err1
 	}
-	log.Info("Managed to start GETH container ", "id", id)
+	eth.logger().Info("Managed to start GETH container ", "id", id)
 
-	ctx1, cancel1 := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel1()
-
-	err = eth.dockerClient.ContainerKill(ctx1, id, "")
+	err = eth.runtime.Kill(id, "")
 	if err != nil {
-		log.Error("Failed to kill GETH container", "err", err)
+		eth.logger().Error("Failed to kill GETH container", "err", err)
 	}
 
-	ctx2, cancel2 := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel2()
-
-	err = eth.dockerClient.ContainerRemove(ctx2, id, types.ContainerRemoveOptions{Force: true})
+	err = eth.runtime.Remove(id)
 	if err != nil {
-		log.Error("Failed to remove GETH container", "err", err)
+		eth.logger().Error("Failed to remove GETH container", "err", err)
 	}
 
 	return nil
@@ -234,36 +262,34 @@ func logCancellationError(msg string) {
 }
 
 func (eth *ethereum) Start() error {
+	eth.ok = false
+	eth.paused = false
+
 	defer func() {
 		if eth.logging {
-			go eth.showLog(context.Background())
+			go eth.showLog(context.Background(), eth.containerID)
 		}
 	}()
 
-	exposedPorts := nat.PortSet{}
-	portBindings := nat.PortMap{}
-
+	var ports []PortBinding
 	if eth.rpcPort != "" {
-		port := fmt.Sprintf("%d", utils.RPCPortFlag.Value)
-		exposedPorts[nat.Port(port)] = struct{}{}
-		portBindings[nat.Port(port)] = []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: eth.rpcPort,
-			},
-		}
+		ports = append(ports, PortBinding{
+			ContainerPort: utils.RPCPortFlag.Value,
+			HostPort:      atoi(eth.rpcPort),
+			Flag:          "--" + utils.RPCPortFlag.Name,
+		})
 	}
-
 	if eth.wsPort != "" {
-		port := fmt.Sprintf("%d", utils.WSPortFlag.Value)
-		exposedPorts[nat.Port(port)] = struct{}{}
-		portBindings[nat.Port(port)] = []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: eth.wsPort,
-			},
-		}
+		ports = append(ports, PortBinding{
+			ContainerPort: utils.WSPortFlag.Value,
+			HostPort:      atoi(eth.wsPort),
+			Flag:          "--" + utils.WSPortFlag.Name,
+		})
 	}
+	ports = append(ports, PortBinding{
+		ContainerPort: utils.ListenPortFlag.Value,
+		Flag:          "--" + utils.ListenPortFlag.Name,
+	})
 
 	var binds []string
 	binds = append(binds, eth.dockerBinds...)
@@ -271,41 +297,26 @@ func (eth *ethereum) Start() error {
 		binds = append(binds, eth.dataDir+":"+utils.DataDirFlag.Value.Value)
 	}
 
-	var networkingConfig *network.NetworkingConfig
-	if eth.ip != "" && eth.dockerNetworkName != "" {
-		endpointsConfig := make(map[string]*network.EndpointSettings)
-		endpointsConfig[eth.dockerNetworkName] = &network.EndpointSettings{
-			IPAMConfig: &network.EndpointIPAMConfig{
-				IPv4Address: eth.ip,
-			},
-		}
-		networkingConfig = &network.NetworkingConfig{
-			EndpointsConfig: endpointsConfig,
-		}
-	}
-
-	resp, err := eth.dockerClient.ContainerCreate(context.Background(),
-		&container.Config{
-			Hostname:     "geth-" + eth.hostName,
-			Image:        eth.Image(),
-			Cmd:          eth.flags,
-			ExposedPorts: exposedPorts,
-			Env:          eth.DockerEnv(),
-		},
-		&container.HostConfig{
-			Binds:        binds,
-			PortBindings: portBindings,
-		}, networkingConfig, "")
+	id, err := eth.runtime.Create(&Spec{
+		Hostname:    "geth-" + eth.hostName,
+		Image:       eth.Image(),
+		Cmd:         eth.flags,
+		Env:         eth.DockerEnv(),
+		Binds:       binds,
+		Ports:       ports,
+		NetworkName: eth.dockerNetworkName,
+		IP:          eth.ip,
+	})
 	if err != nil {
-		log.Error("Failed to create container", "err", err)
+		eth.logger().Error("Failed to create container", "err", err)
 		return err
 	}
 
-	eth.containerID = resp.ID
+	eth.containerID = id
 
-	err = eth.dockerClient.ContainerStart(context.Background(), eth.containerID, types.ContainerStartOptions{})
+	err = eth.runtime.Start(eth.containerID)
 	if err != nil {
-		log.Error("Failed to start container", "ip", eth.ip, "err", err)
+		eth.logger().Error("Failed to start container", "err", err)
 		return err
 	}
 
@@ -329,64 +340,137 @@ func (eth *ethereum) Start() error {
 		return errors.New("failed to start geth")
 	}
 
-	containerIP := eth.ip
+	status, err := eth.runtime.Inspect(eth.containerID)
+	if err != nil {
+		eth.logger().Error("Failed to inspect container", "err", err)
+		return err
+	}
+	containerIP := status.IP
 	if containerIP == "" {
-		containerJSON, err := eth.dockerClient.ContainerInspect(context.Background(), eth.containerID)
-		if err != nil {
-			log.Error("Failed to inspect container", "err", err)
-			return err
-		}
-		containerIP = containerJSON.NetworkSettings.IPAddress
+		containerIP = eth.ip
+	}
+	listenPort := utils.ListenPortFlag.Value
+	if port, ok := status.Ports[listenPort]; ok {
+		listenPort = port
 	}
 
 	if eth.key != nil {
+		eth.nodeMutex.Lock()
 		eth.node = discover.NewNode(
 			discover.PubkeyID(&eth.key.PublicKey),
 			net.ParseIP(containerIP),
 			0,
-			uint16(utils.ListenPortFlag.Value))
+			uint16(listenPort))
+		eth.nodeMutex.Unlock()
 	}
 
 	return nil
 }
 
 func (eth *ethereum) Stop() error {
-	duration := time.Duration(30 * time.Second)
-	err := eth.dockerClient.ContainerStop(context.Background(), eth.containerID, &duration)
+	if eth.paused {
+		eth.Unpause()
+	}
+
+	err := eth.runtime.Stop(eth.containerID, stopTimeout)
 	if err != nil {
-		log.Error("Failed to stop GETH container", "err", err)
+		eth.logger().Error("Failed to stop GETH container", "err", err)
 		//return err
 	}
 
 	//defer os.RemoveAll(eth.dataDir)
 
-	return eth.dockerClient.ContainerRemove(context.Background(), eth.containerID,
-		types.ContainerRemoveOptions{
-			Force: true,
-		})
+	eth.ok = false
+	return eth.runtime.Remove(eth.containerID)
+}
+
+func (eth *ethereum) Kill() error {
+	eth.logger().Info("Killing fullnode")
+	if err := eth.runtime.Kill(eth.containerID, "SIGKILL"); err != nil {
+		eth.logger().Error("Failed to kill GETH container", "err", err)
+		return err
+	}
+
+	eth.ok = false
+	eth.paused = false
+	return eth.runtime.Remove(eth.containerID)
+}
+
+func (eth *ethereum) Restart() error {
+	// The chain lives in the container without a host data dir
+	if eth.dataDir == "" {
+		return errors.New("cannot restart a fullnode without a host data dir")
+	}
+
+	eth.logger().Info("Restarting fullnode")
+	if eth.ok {
+		if err := eth.Stop(); err != nil {
+			return err
+		}
+	}
+	return eth.Start()
+}
+
+func (eth *ethereum) Upgrade(tag string) error {
+	if eth.dataDir == "" {
+		return errors.New("cannot upgrade a fullnode without a host data dir")
+	}
+
+	image := eth.imageRepository + ":" + tag
+	if err := eth.runtime.EnsureImage(image, ioutil.Discard); err != nil {
+		eth.logger().Error("Failed to pull image", "image", image, "err", err)
+		return err
+	}
+
+	eth.logger().Info("Upgrading fullnode", "from", eth.Image(), "to", image)
+	if eth.ok {
+		if err := eth.Stop(); err != nil {
+			return err
+		}
+	}
+	eth.imageTag = tag
+	return eth.Start()
+}
+
+func (eth *ethereum) Pause() error {
+	if err := eth.runtime.Pause(eth.containerID); err != nil {
+		eth.logger().Error("Failed to pause GETH container", "err", err)
+		return err
+	}
+	eth.paused = true
+	return nil
+}
+
+func (eth *ethereum) Unpause() error {
+	if err := eth.runtime.Unpause(eth.containerID); err != nil {
+		eth.logger().Error("Failed to unpause GETH container", "err", err)
+		return err
+	}
+	eth.paused = false
+	return nil
+}
+
+func (eth *ethereum) Exec(cmd ...string) error {
+	err := eth.runtime.Exec(eth.containerID, cmd...)
+	if err != nil {
+		eth.logger().Error("Failed to exec in GETH container", "cmd", cmd, "err", err)
+	}
+	return err
 }
 
 func (eth *ethereum) Wait(t time.Duration) error {
-	ctx, cancel := context.WithTimeout(context.Background(), t)
-	defer cancel()
-	eth.dockerClient.ContainerWait(ctx, eth.containerID, "")
+	eth.runtime.Wait(eth.containerID, t)
 	return nil
 }
 
 func (eth *ethereum) Running() bool {
-	containers, err := eth.dockerClient.ContainerList(context.Background(), types.ContainerListOptions{})
+	status, err := eth.runtime.Inspect(eth.containerID)
 	if err != nil {
-		log.Error("Failed to list containers", "err", err)
+		eth.logger().Error("Failed to inspect container", "err", err)
 		return false
 	}
 
-	for _, c := range containers {
-		if c.ID == eth.containerID {
-			return true
-		}
-	}
-
-	return false
+	return status.Running
 }
 
 func (eth *ethereum) NewClient() client.Client {
@@ -408,6 +492,8 @@ func (eth *ethereum) NewClient() client.Client {
 }
 
 func (eth *ethereum) NodeAddress() string {
+	eth.nodeMutex.RLock()
+	defer eth.nodeMutex.RUnlock()
 	if eth.node != nil {
 		return (*eth.node).String()
 	}
@@ -429,7 +515,7 @@ func (eth *ethereum) ConsensusMonitor(errCh chan<- error, quit chan struct{}) {
 
 	sub, err := cli.SubscribeNewHead(ctx, subCh)
 	if err != nil {
-		log.Error("Failed to subscribe new head", "err", err)
+		eth.logger().Error("Failed to subscribe new head", "err", err)
 		errCh <- err
 		return
 	}
@@ -441,7 +527,7 @@ func (eth *ethereum) ConsensusMonitor(errCh chan<- error, quit chan struct{}) {
 	for {
 		select {
 		case err := <-sub.Err():
-			log.Error("Connection lost", "err", err)
+			eth.logger().Error("Connection lost", "err", err)
 			errCh <- err
 			return
 		case <-timer.C: // FIXME: this event may be missed
@@ -497,194 +583,56 @@ func (eth *ethereum) WaitForProposed(expectedAddress common.Address, timeout tim
 	}
 }
 
-func (eth *ethereum) WaitForPeersConnected(expectedPeercount int) error {
-	cli := eth.NewClient()
-	if cli == nil {
-		return errors.New("failed to retrieve client")
-	}
-	defer cli.Close()
-
-	ticker := time.NewTicker(time.Second * 1)
-	defer ticker.Stop()
-	for range ticker.C {
-		infos, err := cli.AdminPeers(context.Background())
-		if err != nil {
-			return err
-		}
-		if len(infos) < expectedPeercount {
-			continue
-		} else {
-			break
-		}
-	}
-
-	return nil
+func (eth *ethereum) WaitForPeersConnected(expectedPeercount int, timeout ...time.Duration) error {
+	ctx, cancel := waitContext(timeout)
+	defer cancel()
+	return eth.WaitForPeersConnectedContext(ctx, expectedPeercount)
 }
 
 func (eth *ethereum) WaitForBlocks(num int, waitingTime ...time.Duration) error {
-	var first *big.Int
-
-	cli := eth.NewClient()
-	if cli == nil {
-		return errors.New("failed to retrieve client")
-	}
-	defer cli.Close()
-
-	var t time.Duration
-	if len(waitingTime) > 0 {
-		t = waitingTime[0]
-	} else {
-		t = 1 * time.Hour
-	}
-
-	timeout := time.After(t)
-	ticker := time.NewTicker(time.Millisecond * 500)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-timeout:
-			ticker.Stop()
-			return ErrNoBlock
-		case <-ticker.C:
-			n, err := cli.BlockNumber(context.Background())
-			if err != nil {
-				return err
-			}
-			if first == nil {
-				first = new(big.Int).Set(n)
-				continue
-			}
-			// Check if new blocks are getting generated
-			if new(big.Int).Sub(n, first).Int64() >= int64(num) {
-				return nil
-			}
-		}
-	}
+	ctx, cancel := waitContext(waitingTime)
+	defer cancel()
+	return eth.WaitForBlocksContext(ctx, num)
 }
 
-func (eth *ethereum) WaitForBlockHeight(num int) error {
-	cli := eth.NewClient()
-	if cli == nil {
-		return errors.New("failed to retrieve client")
-	}
-	defer cli.Close()
-
-	ticker := time.NewTicker(time.Millisecond * 500)
-	defer ticker.Stop()
-	for range ticker.C {
-		n, err := cli.BlockNumber(context.Background())
-		if err != nil {
-			return err
-		}
-		if n.Int64() >= int64(num) {
-			break
-		}
-	}
-
-	return nil
+func (eth *ethereum) WaitForBlockHeight(num int, timeout ...time.Duration) error {
+	ctx, cancel := waitContext(timeout)
+	defer cancel()
+	return eth.WaitForBlockHeightContext(ctx, num)
 }
 
 func (eth *ethereum) WaitForNoBlocks(num int, duration time.Duration) error {
-	var first *big.Int
-
-	cli := eth.NewClient()
-	if cli == nil {
-		return errors.New("failed to retrieve client")
-	}
-
-	timeout := time.After(duration)
-	ticker := time.NewTicker(time.Millisecond * 500)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-timeout:
-			return nil
-		case <-ticker.C:
-			n, err := cli.BlockNumber(context.Background())
-			if err != nil {
-				return err
-			}
-			if first == nil {
-				first = new(big.Int).Set(n)
-				continue
-			}
-			// Check if new blocks are getting generated
-			if new(big.Int).Sub(n, first).Int64() > int64(num) {
-				return errors.New("generated more blocks than expected")
-			}
-		}
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+	return eth.WaitForNoBlocksContext(ctx, num)
 }
 
 func (eth *ethereum) WaitForBalances(addrs []common.Address, duration ...time.Duration) error {
+	ctx, cancel := waitContext(duration)
+	defer cancel()
+	return eth.WaitForBalancesContext(ctx, addrs)
+}
+
+// ----------------------------------------------------------------------------
+
+func (eth *ethereum) AddPeer(address string) error {
 	cli := eth.NewClient()
 	if cli == nil {
 		return errors.New("failed to retrieve client")
 	}
+	defer cli.Close()
 
-	var t time.Duration
-	if len(duration) > 0 {
-		t = duration[0]
-	} else {
-		t = 1 * time.Hour
-	}
-
-	waitBalance := func(addr common.Address) error {
-		timeout := time.After(t)
-		ticker := time.NewTicker(time.Millisecond * 500)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-timeout:
-				return ErrTimeout
-			case <-ticker.C:
-				n, err := cli.BalanceAt(context.Background(), addr, nil)
-				if err != nil {
-					return err
-				}
-
-				// Check if new blocks are getting generated
-				if n.Uint64() <= 0 {
-					continue
-				} else {
-					return nil
-				}
-			}
-		}
-	}
-
-	var wg sync.WaitGroup
-	errc := make(chan error, len(addrs))
-	wg.Add(len(addrs))
-
-	for _, addr := range addrs {
-		addr := addr
-		go func() {
-			defer wg.Done()
-			errc <- waitBalance(addr)
-		}()
-	}
-	// Wait for the first error, then terminate the others.
-	var err error
-	for i := 0; i < len(addrs); i++ {
-		if err = <-errc; err != nil {
-			break
-		}
-	}
-	wg.Wait()
-	return err
+	return cli.AddPeer(context.Background(), address)
 }
 
-// ----------------------------------------------------------------------------
-
-func (eth *ethereum) AddPeer(address string) error {
+func (eth *ethereum) RemovePeer(address string) error {
 	cli := eth.NewClient()
 	if cli == nil {
 		return errors.New("failed to retrieve client")
 	}
 	defer cli.Close()
 
-	return cli.AddPeer(context.Background(), address)
+	return cli.RemovePeer(context.Background(), address)
 }
 
 func (eth *ethereum) StartMining() error {
@@ -721,14 +669,41 @@ func (eth *ethereum) DockerBinds() []string {
 
 // ----------------------------------------------------------------------------
 
-func (eth *ethereum) showLog(context context.Context) {
-	if readCloser, err := eth.dockerClient.ContainerLogs(context, eth.containerID,
-		types.ContainerLogsOptions{ShowStderr: true, Follow: true}); err == nil {
-		defer readCloser.Close()
-		_, err = io.Copy(os.Stdout, readCloser)
-		if err != nil && err != io.EOF {
-			log.Error("Failed to print container log", "err", err)
-			return
-		}
+// Name returns the name of the fullnode in log lines.
+func (eth *ethereum) Name() string {
+	if eth.name != "" {
+		return eth.name
+	}
+	if eth.hostName != "" {
+		return "geth-" + eth.hostName
+	}
+	return "geth-" + eth.ip
+}
+
+// logger returns a logger with the node, container and ip fields of the
+// fullnode.
+func (eth *ethereum) logger() log15.Logger {
+	return log.New("node", eth.Name(), "container", shortID(eth.containerID), "ip", eth.ip)
+}
+
+// nodeLogger returns the logger of a fullnode.
+func nodeLogger(geth Ethereum) log15.Logger {
+	if eth, ok := geth.(*ethereum); ok {
+		return eth.logger()
+	}
+	return log.New("ip", geth.IP())
+}
+
+func (eth *ethereum) Logs(ctx context.Context, follow bool, stdout, stderr io.Writer) error {
+	return eth.runtime.Logs(ctx, eth.containerID, follow, stdout, stderr)
+}
+
+func (eth *ethereum) showLog(ctx context.Context, id string) {
+	stdout := newPrefixWriter(os.Stdout, eth.Name()+" | ")
+	stderr := newPrefixWriter(os.Stdout, eth.Name()+" | ")
+	defer stdout.Flush()
+	defer stderr.Flush()
+	if err := eth.runtime.Logs(ctx, id, true, stdout, stderr); err != nil {
+		eth.logger().Error("Failed to print container log", "err", err)
 	}
 }
@@ -19,21 +19,35 @@
 package container
 
 import (
+	"context"
 	"testing"
 
-	"github.com/docker/docker/client"
+	docker "github.com/docker/docker/client"
 	"github.com/phayes/freeport"
 )
 
+// requireDocker skips the tests which run real containers when no Docker
+// daemon answers, the tests on the fake runtime still run.
+func requireDocker(t *testing.T) {
+	c, err := docker.NewEnvClient()
+	if err == nil {
+		_, err = c.Ping(context.Background())
+	}
+	if err != nil {
+		t.Skip("Docker is not available:", err)
+	}
+}
+
 func TestEthereumContainer(t *testing.T) {
+	requireDocker(t)
 
-	dockerClient, err := client.NewEnvClient()
+	runtime, err := NewEnvDockerRuntime()
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 
 	geth := NewEthereum(
-		dockerClient,
+		runtime,
 		ImageRepository(GetGoSmiloImage()),
 		ImageTag("latest"),
 		DataDir("/data"),
@@ -44,6 +58,9 @@ func TestEthereumContainer(t *testing.T) {
 		WebSocketOrigin("*"),
 		NoDiscover(),
 	)
+	if geth == nil {
+		t.Fatal("Unable to create fullnode")
+	}
 
 	err = geth.Start()
 	if err != nil {
@@ -0,0 +1,409 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"go-smilo/src/blockchain/smilobft/core/types"
+	"go-smilo/src/blockchain/smilobft/p2p/discover"
+	"go-smilo/src/blockchain/smilobft/rpc"
+
+	"go-smilo/src/blockchain/regression/src/client"
+)
+
+// fakeBackend is the state of a fake node, it records the calls made by the
+// blockchain so that tests can verify them.
+type fakeBackend struct {
+	rt *fakeRuntime
+	ip string
+	// id is the node ID, it is known once the node is started
+	id string
+
+	mutex sync.Mutex
+	// running is false while the container of the node is stopped, its
+	// links are down then
+	running   bool
+	peers     []string
+	proposals map[common.Address]bool
+	fullnodes []common.Address
+	chain     []*types.Header
+	// dropped heights are not served, as if the node missed them
+	dropped map[uint64]bool
+	// extra returns the extra data of a new block, empty if it is nil
+	extra func(number uint64) []byte
+	// fullnodesAt returns the fullnodes after the block at number, the
+	// fullnodes are used if it is nil
+	fullnodesAt func(number uint64) []common.Address
+	// queried are the heights the fullnodes were asked for
+	queried []uint64
+	heads   []chan *types.Header
+}
+
+func newFakeBackend(rt *fakeRuntime, ip string) *fakeBackend {
+	return &fakeBackend{
+		rt:        rt,
+		ip:        ip,
+		proposals: make(map[common.Address]bool),
+		chain:     []*types.Header{newFakeHeader(nil, nil)},
+		dropped:   make(map[uint64]bool),
+	}
+}
+
+// newFakeHeader returns an empty block on top of parent.
+func newFakeHeader(parent *types.Header, extra []byte) *types.Header {
+	header := &types.Header{
+		UncleHash:   types.EmptyUncleHash,
+		TxHash:      types.EmptyRootHash,
+		ReceiptHash: types.EmptyRootHash,
+		Difficulty:  big.NewInt(1),
+		Number:      big.NewInt(0),
+		Time:        big.NewInt(0),
+		Extra:       extra,
+	}
+	if parent != nil {
+		header.ParentHash = parent.Hash()
+		header.Number = new(big.Int).Add(parent.Number, common.Big1)
+		header.Time = new(big.Int).Add(parent.Time, common.Big1)
+	}
+	return header
+}
+
+// Mine appends num blocks to the chain and notifies the head subscribers.
+func (b *fakeBackend) Mine(num int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for i := 0; i < num; i++ {
+		parent := b.chain[len(b.chain)-1]
+		var extra []byte
+		if b.extra != nil {
+			extra = b.extra(parent.Number.Uint64() + 1)
+		}
+		header := newFakeHeader(parent, extra)
+		b.chain = append(b.chain, header)
+		for _, ch := range b.heads {
+			select {
+			case ch <- header:
+			default:
+			}
+		}
+	}
+}
+
+func (b *fakeBackend) SetExtra(extra func(number uint64) []byte) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.extra = extra
+}
+
+func (b *fakeBackend) SetFullnodes(fullnodes []common.Address) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.fullnodes = fullnodes
+}
+
+func (b *fakeBackend) SetFullnodesAt(fullnodesAt func(number uint64) []common.Address) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.fullnodesAt = fullnodesAt
+}
+
+func (b *fakeBackend) Queried() []uint64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]uint64{}, b.queried...)
+}
+
+func (b *fakeBackend) Drop(number uint64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.dropped[number] = true
+}
+
+// header returns the header at number, nil if there is none.
+func (b *fakeBackend) header(number rpc.BlockNumber) *types.Header {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if number < 0 {
+		return b.chain[len(b.chain)-1]
+	}
+	if int(number) >= len(b.chain) || b.dropped[uint64(number)] {
+		return nil
+	}
+	return b.chain[number]
+}
+
+func (b *fakeBackend) subscribeHeads() chan *types.Header {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	ch := make(chan *types.Header, 16)
+	b.heads = append(b.heads, ch)
+	return ch
+}
+
+func (b *fakeBackend) unsubscribeHeads(ch chan *types.Header) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for i, head := range b.heads {
+		if head == ch {
+			b.heads = append(b.heads[:i], b.heads[i+1:]...)
+			return
+		}
+	}
+}
+
+func (b *fakeBackend) setRunning(running bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.running = running
+}
+
+func (b *fakeBackend) isRunning() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.running
+}
+
+func (b *fakeBackend) Peers() []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]string{}, b.peers...)
+}
+
+func (b *fakeBackend) Proposals() map[common.Address]bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	proposals := make(map[common.Address]bool, len(b.proposals))
+	for addr, auth := range b.proposals {
+		proposals[addr] = auth
+	}
+	return proposals
+}
+
+type FakeAdminAPI struct {
+	b *fakeBackend
+}
+
+func (api *FakeAdminAPI) AddPeer(url string) (bool, error) {
+	api.b.mutex.Lock()
+	defer api.b.mutex.Unlock()
+	api.b.peers = append(api.b.peers, url)
+	return true, nil
+}
+
+func (api *FakeAdminAPI) RemovePeer(url string) (bool, error) {
+	api.b.mutex.Lock()
+	defer api.b.mutex.Unlock()
+	var peers []string
+	for _, peer := range api.b.peers {
+		if peer != url {
+			peers = append(peers, peer)
+		}
+	}
+	api.b.peers = peers
+	return true, nil
+}
+
+func (api *FakeAdminAPI) NodeInfo() map[string]interface{} {
+	return map[string]interface{}{
+		"id":   api.b.id,
+		"name": "fake/" + api.b.ip,
+	}
+}
+
+// Peers returns the running nodes this node dialled and the running nodes
+// which dialled it.
+func (api *FakeAdminAPI) Peers() ([]map[string]interface{}, error) {
+	running := make(map[string]bool)
+	for _, other := range api.b.rt.allBackends() {
+		if other.isRunning() {
+			running[other.ip] = true
+		}
+	}
+
+	ids := make(map[string]bool)
+	for _, url := range api.b.Peers() {
+		node, err := discover.ParseNode(url)
+		if err != nil {
+			return nil, err
+		}
+		if running[node.IP.String()] {
+			ids[node.ID.String()] = true
+		}
+	}
+	for _, other := range api.b.rt.allBackends() {
+		if other == api.b || !running[other.ip] {
+			continue
+		}
+		for _, url := range other.Peers() {
+			node, err := discover.ParseNode(url)
+			if err != nil {
+				return nil, err
+			}
+			if node.IP.String() == api.b.ip {
+				ids[other.id] = true
+			}
+		}
+	}
+
+	var peers []map[string]interface{}
+	for id := range ids {
+		peers = append(peers, map[string]interface{}{
+			"id": id,
+		})
+	}
+	return peers, nil
+}
+
+type FakeEthAPI struct {
+	b *fakeBackend
+}
+
+func (api *FakeEthAPI) BlockNumber() hexutil.Uint64 {
+	api.b.mutex.Lock()
+	defer api.b.mutex.Unlock()
+	return hexutil.Uint64(len(api.b.chain) - 1)
+}
+
+// GetBlockByNumber returns the header as a block without transactions.
+func (api *FakeEthAPI) GetBlockByNumber(number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	header := api.b.header(number)
+	if header == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	var block map[string]interface{}
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return nil, err
+	}
+	block["transactions"] = []interface{}{}
+	block["uncles"] = []interface{}{}
+	return block, nil
+}
+
+func (api *FakeEthAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	sub := notifier.CreateSubscription()
+	heads := api.b.subscribeHeads()
+	go func() {
+		defer api.b.unsubscribeHeads(heads)
+		for {
+			select {
+			case header := <-heads:
+				notifier.Notify(sub.ID, header)
+			case <-sub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+type FakeSmiloBFTAPI struct {
+	b *fakeBackend
+}
+
+// Propose records the proposal and applies it to the fullnodes right away, as
+// if the other fullnodes voted the same.
+func (api *FakeSmiloBFTAPI) Propose(address common.Address, auth bool) error {
+	api.b.mutex.Lock()
+	defer api.b.mutex.Unlock()
+	api.b.proposals[address] = auth
+
+	var fullnodes []common.Address
+	for _, addr := range api.b.fullnodes {
+		if addr != address {
+			fullnodes = append(fullnodes, addr)
+		}
+	}
+	if auth {
+		fullnodes = append(fullnodes, address)
+	}
+	api.b.fullnodes = fullnodes
+	return nil
+}
+
+func (api *FakeSmiloBFTAPI) Discard(address common.Address) {
+	api.b.mutex.Lock()
+	defer api.b.mutex.Unlock()
+	delete(api.b.proposals, address)
+}
+
+func (api *FakeSmiloBFTAPI) Candidates() map[common.Address]bool {
+	api.b.mutex.Lock()
+	defer api.b.mutex.Unlock()
+	proposals := make(map[common.Address]bool, len(api.b.proposals))
+	for addr, auth := range api.b.proposals {
+		proposals[addr] = auth
+	}
+	return proposals
+}
+
+func (api *FakeSmiloBFTAPI) GetFullnodes(number *rpc.BlockNumber) []common.Address {
+	api.b.mutex.Lock()
+	defer api.b.mutex.Unlock()
+	if number != nil && number.Int64() >= 0 {
+		api.b.queried = append(api.b.queried, uint64(number.Int64()))
+		if api.b.fullnodesAt != nil {
+			return api.b.fullnodesAt(uint64(number.Int64()))
+		}
+	}
+	return append([]common.Address{}, api.b.fullnodes...)
+}
+
+func (api *FakeSmiloBFTAPI) GetFullnodesAtHash(hash common.Hash) []common.Address {
+	return api.GetFullnodes(nil)
+}
+
+// GetSnapshot returns the fullnodes and the pending proposals of the node as
+// the snapshot of the latest block.
+func (api *FakeSmiloBFTAPI) GetSnapshot(number *rpc.BlockNumber) *client.Snapshot {
+	head := api.b.header(rpc.LatestBlockNumber)
+
+	api.b.mutex.Lock()
+	defer api.b.mutex.Unlock()
+	snapshot := &client.Snapshot{
+		Number:    head.Number.Uint64(),
+		Hash:      head.Hash(),
+		Tally:     make(map[common.Address]client.Tally),
+		Fullnodes: append([]common.Address{}, api.b.fullnodes...),
+	}
+	for addr, auth := range api.b.proposals {
+		snapshot.Tally[addr] = client.Tally{Authorize: auth, Votes: 1}
+	}
+	return snapshot
+}
+
+func (api *FakeSmiloBFTAPI) GetSnapshotAtHash(hash common.Hash) *client.Snapshot {
+	return api.GetSnapshot(nil)
+}
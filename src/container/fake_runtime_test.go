@@ -0,0 +1,284 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"go-smilo/src/blockchain/smilobft/cmd/utils"
+	"go-smilo/src/blockchain/smilobft/p2p/discover"
+	"go-smilo/src/blockchain/smilobft/rpc"
+)
+
+// fakeRuntime runs no containers at all. Containers publishing a port serve a
+// fakeBackend over JSON-RPC on it, all other containers exit immediately.
+type fakeRuntime struct {
+	mutex      sync.Mutex
+	counter    int
+	containers map[string]*fakeContainer
+	// backends are keyed by IP so that they survive container restarts
+	backends map[string]*fakeBackend
+}
+
+type fakeContainer struct {
+	spec    *Spec
+	running bool
+	paused  bool
+	execs   [][]string
+	server  *fakeServer
+}
+
+func newFakeRuntime() *fakeRuntime {
+	return &fakeRuntime{
+		containers: make(map[string]*fakeContainer),
+		backends:   make(map[string]*fakeBackend),
+	}
+}
+
+func (r *fakeRuntime) Host() string {
+	return "127.0.0.1"
+}
+
+func (r *fakeRuntime) EnsureImage(image string, out io.Writer) error {
+	return nil
+}
+
+func (r *fakeRuntime) Create(spec *Spec) (string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.counter++
+	id := fmt.Sprintf("fake-%d", r.counter)
+	r.containers[id] = &fakeContainer{spec: spec}
+	return id, nil
+}
+
+func (r *fakeRuntime) Start(id string) error {
+	c, err := r.container(id)
+	if err != nil {
+		return err
+	}
+
+	for _, port := range c.spec.Ports {
+		if port.HostPort == 0 {
+			continue
+		}
+		b := r.backend(c.spec.IP)
+		b.id = nodeID(c.spec)
+		c.server, err = r.serve(b, port.HostPort)
+		if err != nil {
+			return err
+		}
+		b.setRunning(true)
+		c.running = true
+	}
+	return nil
+}
+
+func (r *fakeRuntime) Stop(id string, timeout time.Duration) error {
+	c, err := r.container(id)
+	if err != nil {
+		return err
+	}
+	if c.server != nil {
+		c.server.Close()
+		c.server = nil
+		r.backend(c.spec.IP).setRunning(false)
+	}
+	c.running = false
+	return nil
+}
+
+func (r *fakeRuntime) Kill(id string, signal string) error {
+	c, err := r.container(id)
+	if err != nil {
+		return err
+	}
+	if !c.running {
+		return fmt.Errorf("container %s is not running", id)
+	}
+	return r.Stop(id, 0)
+}
+
+// Pause keeps the backend serving, paused containers are only recorded.
+func (r *fakeRuntime) Pause(id string) error {
+	c, err := r.container(id)
+	if err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !c.running || c.paused {
+		return fmt.Errorf("container %s is not running", id)
+	}
+	c.paused = true
+	return nil
+}
+
+func (r *fakeRuntime) Unpause(id string) error {
+	c, err := r.container(id)
+	if err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !c.paused {
+		return fmt.Errorf("container %s is not paused", id)
+	}
+	c.paused = false
+	return nil
+}
+
+func (r *fakeRuntime) Remove(id string) error {
+	if err := r.Stop(id, 0); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.containers, id)
+	return nil
+}
+
+func (r *fakeRuntime) Inspect(id string) (*Status, error) {
+	c, err := r.container(id)
+	if err != nil {
+		return nil, err
+	}
+	return &Status{Running: c.running, IP: c.spec.IP}, nil
+}
+
+func (r *fakeRuntime) Wait(id string, timeout time.Duration) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeRuntime) Logs(ctx context.Context, id string, follow bool, stdout, stderr io.Writer) error {
+	if _, err := r.container(id); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(stdout, "logs of %s\n", id)
+	return err
+}
+
+func (r *fakeRuntime) Exec(id string, cmd ...string) error {
+	c, err := r.container(id)
+	if err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	c.execs = append(c.execs, cmd)
+	return nil
+}
+
+func (r *fakeRuntime) Attach(id string, stdin []byte) error {
+	return nil
+}
+
+func (r *fakeRuntime) container(id string) (*fakeContainer, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c, ok := r.containers[id]
+	if !ok {
+		return nil, fmt.Errorf("no such container %s", id)
+	}
+	return c, nil
+}
+
+// backend returns the backend of the node with the given IP.
+func (r *fakeRuntime) backend(ip string) *fakeBackend {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	b, ok := r.backends[ip]
+	if !ok {
+		b = newFakeBackend(r, ip)
+		r.backends[ip] = b
+	}
+	return b
+}
+
+func (r *fakeRuntime) allBackends() []*fakeBackend {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var backends []*fakeBackend
+	for _, b := range r.backends {
+		backends = append(backends, b)
+	}
+	return backends
+}
+
+// nodeID reads the ID of a node from the node key in its data directory.
+func nodeID(spec *Spec) string {
+	for _, bind := range spec.Binds {
+		parts := strings.Split(bind, ":")
+		if len(parts) < 2 || parts[1] != utils.DataDirFlag.Value.Value {
+			continue
+		}
+		key, err := crypto.LoadECDSA(filepath.Join(parts[0], "geth", "nodekey"))
+		if err != nil {
+			return ""
+		}
+		return discover.PubkeyID(&key.PublicKey).String()
+	}
+	return ""
+}
+
+// fakeServer serves a fakeBackend, closing it drops the open connections
+// and subscriptions too.
+type fakeServer struct {
+	http *http.Server
+	rpc  *rpc.Server
+}
+
+func (s *fakeServer) Close() {
+	s.http.Close()
+	s.rpc.Stop()
+}
+
+func (r *fakeRuntime) serve(b *fakeBackend, port int) (*fakeServer, error) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("admin", &FakeAdminAPI{b}); err != nil {
+		return nil, err
+	}
+	if err := server.RegisterName("eth", &FakeEthAPI{b}); err != nil {
+		return nil, err
+	}
+	if err := server.RegisterName("smilobft", &FakeSmiloBFTAPI{b}); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return nil, err
+	}
+	httpServer := &http.Server{Handler: server.WebsocketHandler([]string{"*"})}
+	go httpServer.Serve(listener)
+	return &fakeServer{http: httpServer, rpc: server}, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import "fmt"
+
+// FaultyBehaviour is the Byzantine behaviour of a faulty fullnode, its value
+// is the faulty mode passed to the regression_test image.
+type FaultyBehaviour int
+
+const (
+	// Honest runs the fullnode without faults
+	Honest FaultyBehaviour = iota
+	// RandomFault picks one of the faulty behaviours for every message
+	RandomFault
+	// Silent never broadcasts consensus messages
+	Silent
+	// RandomMessages sends consensus messages with a wrong message code
+	RandomMessages
+	// InvalidSeal signs consensus messages with a modified signature
+	InvalidSeal
+	// EquivocatingProposer proposes whether it is the proposer or not
+	EquivocatingProposer
+	// DelayedCommit sends a round change instead of committing
+	DelayedCommit
+	// BadBlock proposes blocks with a bad body
+	BadBlock
+)
+
+// FaultyTag is the image tag built with the faulty modes.
+const FaultyTag = "regression_test"
+
+var faultyBehaviourNames = []string{
+	Honest:               "honest",
+	RandomFault:          "random fault",
+	Silent:               "silent",
+	RandomMessages:       "random messages",
+	InvalidSeal:          "invalid seal",
+	EquivocatingProposer: "equivocating proposer",
+	DelayedCommit:        "delayed commit",
+	BadBlock:             "bad block",
+}
+
+// FaultyBehaviours returns every faulty behaviour.
+func FaultyBehaviours() []FaultyBehaviour {
+	behaviours := make([]FaultyBehaviour, 0, len(faultyBehaviourNames)-1)
+	for b := RandomFault; int(b) < len(faultyBehaviourNames); b++ {
+		behaviours = append(behaviours, b)
+	}
+	return behaviours
+}
+
+func (b FaultyBehaviour) String() string {
+	if b < 0 || int(b) >= len(faultyBehaviourNames) {
+		return fmt.Sprintf("faulty mode %d", int(b))
+	}
+	return faultyBehaviourNames[b]
+}
+
+func (b FaultyBehaviour) Validate() error {
+	if b < 0 || int(b) >= len(faultyBehaviourNames) {
+		return fmt.Errorf("unknown faulty behaviour %d", int(b))
+	}
+	return nil
+}
+
+// Faulty runs the fullnode with the faulty behaviour b, which requires the
+// FaultyTag image.
+func Faulty(b FaultyBehaviour) Option {
+	if b == Honest {
+		return func(eth *ethereum) {}
+	}
+	return FaultyMode(int(b))
+}
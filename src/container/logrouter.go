@@ -0,0 +1,317 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// logRetention is the number of log lines kept per node for WaitForLog and
+// HasLog.
+const logRetention = 10000
+
+// LogSource is a node whose container logs can be routed, fullnodes and
+// vaults are log sources.
+type LogSource interface {
+	Name() string
+	Logs(ctx context.Context, follow bool, stdout, stderr io.Writer) error
+}
+
+// LogRouter tails the containers of nodes. It writes every line to an output
+// prefixed with the node name and stream, and to a file per node and stream.
+// The last lines of every node are retained to wait or assert on them.
+type LogRouter struct {
+	out io.Writer
+	dir string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	outMutex sync.Mutex
+
+	mutex sync.Mutex
+	lines map[string][]string
+	// following are the nodes whose containers are tailed, a node is
+	// followed once at a time to not retain its lines twice
+	following map[string]bool
+	files     []*os.File
+	waiters   map[*logWaiter]struct{}
+}
+
+type logWaiter struct {
+	node string
+	re   *regexp.Regexp
+	ch   chan string
+}
+
+// NewLogRouter returns a router writing to out and to files in dir, either
+// may be left out.
+func NewLogRouter(out io.Writer, dir string) (*LogRouter, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &LogRouter{
+		out:       out,
+		dir:       dir,
+		ctx:       ctx,
+		cancel:    cancel,
+		lines:     make(map[string][]string),
+		following: make(map[string]bool),
+		waiters:   make(map[*logWaiter]struct{}),
+	}, nil
+}
+
+// Follow tails the containers of the fullnodes, which must be started. A
+// fullnode is followed again once its container stopped, e.g. after a
+// restart.
+func (r *LogRouter) Follow(geths ...Ethereum) error {
+	for _, geth := range geths {
+		if err := r.follow(geth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FollowVaults tails the containers of the vaults, which must be started.
+func (r *LogRouter) FollowVaults(vaults ...Vault) error {
+	for _, ct := range vaults {
+		if err := r.follow(ct); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *LogRouter) follow(src LogSource) error {
+	name := src.Name()
+	r.mutex.Lock()
+	if r.following[name] {
+		r.mutex.Unlock()
+		return fmt.Errorf("%s is already followed", name)
+	}
+	r.following[name] = true
+	r.mutex.Unlock()
+
+	stdout, err := r.streamWriter(name, "stdout")
+	if err != nil {
+		r.unfollow(name)
+		return err
+	}
+	stderr, err := r.streamWriter(name, "stderr")
+	if err != nil {
+		r.unfollow(name)
+		return err
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer r.unfollow(name)
+		defer stdout.Flush()
+		defer stderr.Flush()
+		if err := src.Logs(r.ctx, true, stdout, stderr); err != nil && r.ctx.Err() == nil {
+			log.Error("Failed to follow container log", "node", name, "err", err)
+		}
+	}()
+	return nil
+}
+
+func (r *LogRouter) unfollow(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.following, name)
+}
+
+// streamWriter appends to the file of the stream, the lines of a node
+// before a restart or an upgrade are kept.
+func (r *LogRouter) streamWriter(name, stream string) (*lineWriter, error) {
+	var file *os.File
+	if r.dir != "" {
+		var err error
+		path := filepath.Join(r.dir, fmt.Sprintf("%s.%s.log", name, stream))
+		file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		r.mutex.Lock()
+		r.files = append(r.files, file)
+		r.mutex.Unlock()
+	}
+	return &lineWriter{fn: func(line string) {
+		if file != nil {
+			fmt.Fprintln(file, line)
+		}
+		r.route(name, stream, line)
+	}}, nil
+}
+
+func (r *LogRouter) route(name, stream, line string) {
+	if r.out != nil {
+		r.outMutex.Lock()
+		fmt.Fprintf(r.out, "%s %s | %s\n", name, stream, line)
+		r.outMutex.Unlock()
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	lines := append(r.lines[name], line)
+	if len(lines) > logRetention {
+		lines = lines[len(lines)-logRetention:]
+	}
+	r.lines[name] = lines
+	for w := range r.waiters {
+		if w.node == name && w.re.MatchString(line) {
+			w.ch <- line
+			delete(r.waiters, w)
+		}
+	}
+}
+
+// Lines returns the retained log lines of the node.
+func (r *LogRouter) Lines(node LogSource) []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.lines[node.Name()]...)
+}
+
+// HasLog reports whether a retained log line of the node matches pattern.
+func (r *LogRouter) HasLog(node LogSource, pattern string) (bool, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, ok := matchLine(r.lines[node.Name()], re)
+	return ok, nil
+}
+
+// WaitForLog waits until a log line of the node matches pattern, retained
+// lines included, and returns the line.
+func (r *LogRouter) WaitForLog(node LogSource, pattern string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return r.WaitForLogContext(ctx, node, pattern)
+}
+
+// WaitForLogContext is WaitForLog with the deadline of the context.
+func (r *LogRouter) WaitForLogContext(ctx context.Context, node LogSource, pattern string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	w := &logWaiter{node: node.Name(), re: re, ch: make(chan string, 1)}
+	r.mutex.Lock()
+	if line, ok := matchLine(r.lines[w.node], re); ok {
+		r.mutex.Unlock()
+		return line, nil
+	}
+	r.waiters[w] = struct{}{}
+	r.mutex.Unlock()
+
+	select {
+	case line := <-w.ch:
+		return line, nil
+	case <-ctx.Done():
+		r.mutex.Lock()
+		delete(r.waiters, w)
+		r.mutex.Unlock()
+		// The line may have matched while the waiter was removed
+		select {
+		case line := <-w.ch:
+			return line, nil
+		default:
+		}
+		return "", fmt.Errorf("no log line of %s matched %q: %v", w.node, pattern, ctx.Err())
+	}
+}
+
+// Close stops tailing the containers and closes the files.
+func (r *LogRouter) Close() error {
+	r.cancel()
+	r.wg.Wait()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var err error
+	for _, f := range r.files {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	r.files = nil
+	return err
+}
+
+func matchLine(lines []string, re *regexp.Regexp) (string, bool) {
+	for _, line := range lines {
+		if re.MatchString(line) {
+			return line, true
+		}
+	}
+	return "", false
+}
+
+// ----------------------------------------------------------------------------
+
+// lineWriter calls fn with every complete line written to it.
+type lineWriter struct {
+	buf []byte
+	fn  func(line string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.fn(string(bytes.TrimRight(w.buf[:i], "\r")))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush passes on the last line if it is not terminated.
+func (w *lineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.fn(string(w.buf))
+		w.buf = nil
+	}
+}
+
+// newPrefixWriter writes every line to out with the prefix.
+func newPrefixWriter(out io.Writer, prefix string) *lineWriter {
+	return &lineWriter{fn: func(line string) {
+		fmt.Fprintln(out, prefix+line)
+	}}
+}
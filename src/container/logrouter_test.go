@@ -0,0 +1,203 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLogSource writes its lines in pieces and then the lines sent to it
+// until the context is done or more is closed.
+type fakeLogSource struct {
+	name   string
+	stdout string
+	stderr string
+	more   chan string
+}
+
+func (s *fakeLogSource) Name() string {
+	return s.name
+}
+
+func (s *fakeLogSource) Logs(ctx context.Context, follow bool, stdout, stderr io.Writer) error {
+	for i := 0; i < len(s.stdout); i += 5 {
+		end := i + 5
+		if end > len(s.stdout) {
+			end = len(s.stdout)
+		}
+		stdout.Write([]byte(s.stdout[i:end]))
+	}
+	stderr.Write([]byte(s.stderr))
+	for {
+		select {
+		case line, ok := <-s.more:
+			if !ok {
+				return nil
+			}
+			stderr.Write([]byte(line + "\n"))
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestLogRouter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrouter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	out := &syncBuffer{}
+	r, err := NewLogRouter(out, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	geth := &fakeLogSource{
+		name:   "geth-0",
+		stdout: "first line\r\nsecond line\n",
+		stderr: "INFO Started\n",
+		more:   make(chan string),
+	}
+	vault := &fakeLogSource{name: "vault-0", stdout: "listening\n", more: make(chan string)}
+	for _, src := range []LogSource{geth, vault} {
+		if err := r.follow(src); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Retained lines match at once
+	if line, err := r.WaitForLog(geth, "Start", time.Second); err != nil || line != "INFO Started" {
+		t.Errorf("retained line mismatch: have %q, %v", line, err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		vault.more <- "INFO Committed block 1"
+		geth.more <- "INFO Committed block 1"
+	}()
+	if line, err := r.WaitForLog(geth, `Committed block \d`, 5*time.Second); err != nil || line != "INFO Committed block 1" {
+		t.Errorf("waited line mismatch: have %q, %v", line, err)
+	}
+	if _, err := r.WaitForLog(vault, "Committed block 2", 100*time.Millisecond); err == nil {
+		t.Errorf("expected a timeout")
+	}
+	if ok, err := r.HasLog(vault, "^listening$"); err != nil || !ok {
+		t.Errorf("missing vault line: %v", err)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := r.Lines(geth); len(lines) != 4 || lines[1] != "second line" {
+		t.Errorf("retained lines mismatch: have %q", lines)
+	}
+	if !strings.Contains(out.String(), "geth-0 stdout | first line\n") || !strings.Contains(out.String(), "vault-0 stderr | INFO Committed block 1\n") {
+		t.Errorf("routed output mismatch: have %q", out.String())
+	}
+	raw, err := ioutil.ReadFile(filepath.Join(dir, "geth-0.stderr.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != "INFO Started\nINFO Committed block 1\n" {
+		t.Errorf("node file mismatch: have %q", raw)
+	}
+}
+
+func TestLogRouterFollowAgain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrouter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := NewLogRouter(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	geth := &fakeLogSource{name: "geth-0", stderr: "INFO Started\n", more: make(chan string)}
+	if err := r.follow(geth); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.follow(geth); err == nil {
+		t.Error("expected an error for a node followed twice")
+	}
+	if _, err := r.WaitForLog(geth, "Started", time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stream ends with the container, the restarted one is followed
+	// and its lines are appended to the file
+	close(geth.more)
+	restarted := &fakeLogSource{name: "geth-0", stderr: "INFO Restarted\n", more: make(chan string)}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := r.follow(restarted)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := r.WaitForLog(geth, "Restarted", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if lines := r.Lines(geth); len(lines) != 2 {
+		t.Errorf("retained lines mismatch: have %q", lines)
+	}
+	close(restarted.more)
+	r.wg.Wait()
+
+	raw, err := ioutil.ReadFile(filepath.Join(dir, "geth-0.stderr.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != "INFO Started\nINFO Restarted\n" {
+		t.Errorf("node file mismatch: have %q", raw)
+	}
+}
+
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
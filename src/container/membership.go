@@ -0,0 +1,112 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"fmt"
+	"sync"
+)
+
+// membership is the set of fullnodes, or observers, of a blockchain. Every
+// node gets an index when it joins which is never reused, its name
+// fullnode-<index> stays the same while other nodes join or leave. Positions
+// in the snapshot are the ones topologies refer to.
+type membership struct {
+	mutex     sync.RWMutex
+	fullnodes []Ethereum
+	// joined is the number of fullnodes which ever joined, the index of the
+	// next one
+	joined int
+}
+
+func memberName(index int) string {
+	return fmt.Sprintf("fullnode-%d", index)
+}
+
+func observerName(index int) string {
+	return fmt.Sprintf("observer-%d", index)
+}
+
+func (m *membership) add(geth Ethereum) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.fullnodes = append(m.fullnodes, geth)
+	m.joined++
+}
+
+// remove drops the fullnodes and returns how many of them were members.
+func (m *membership) remove(geths []Ethereum) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	removed := make(map[Ethereum]bool)
+	for _, geth := range geths {
+		removed[geth] = true
+	}
+	// A new slice keeps the snapshots handed out intact
+	fullnodes := make([]Ethereum, 0, len(m.fullnodes))
+	for _, geth := range m.fullnodes {
+		if !removed[geth] {
+			fullnodes = append(fullnodes, geth)
+		}
+	}
+	count := len(m.fullnodes) - len(fullnodes)
+	m.fullnodes = fullnodes
+	return count
+}
+
+// snapshot returns the current fullnodes, callers may keep and iterate it
+// while fullnodes join or leave.
+func (m *membership) snapshot() []Ethereum {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return append([]Ethereum{}, m.fullnodes...)
+}
+
+func (m *membership) len() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.fullnodes)
+}
+
+func (m *membership) next() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.joined
+}
+
+func (m *membership) byName(name string) (Ethereum, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for _, geth := range m.fullnodes {
+		if geth.Name() == name {
+			return geth, true
+		}
+	}
+	return nil, false
+}
+
+// position returns the position of the fullnode in the snapshot it belongs
+// to, or -1.
+func position(fullnodes []Ethereum, geth Ethereum) int {
+	for i, v := range fullnodes {
+		if v == geth {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMembership(t *testing.T) {
+	m := &membership{}
+	var geths []Ethereum
+	for i := 0; i < 4; i++ {
+		geth := &ethereum{name: memberName(m.next())}
+		m.add(geth)
+		geths = append(geths, geth)
+	}
+
+	snapshot := m.snapshot()
+	if n := m.remove(geths[1:2]); n != 1 {
+		t.Errorf("removed count mismatch: have %d, want %d", n, 1)
+	}
+	// Earlier snapshots are not changed
+	if snapshot[1] != geths[1] {
+		t.Error("snapshot was changed by remove")
+	}
+
+	geth := &ethereum{name: memberName(m.next())}
+	m.add(geth)
+	if geth.Name() != "fullnode-4" {
+		t.Errorf("name mismatch: have %s, want %s", geth.Name(), "fullnode-4")
+	}
+	if v, ok := m.byName("fullnode-2"); !ok || v != geths[2] {
+		t.Errorf("fullnode-2 mismatch: have %v", v)
+	}
+	if _, ok := m.byName("fullnode-1"); ok {
+		t.Error("removed fullnode is still a member")
+	}
+	if p := position(m.snapshot(), geths[2]); p != 1 {
+		t.Errorf("position mismatch: have %d, want %d", p, 1)
+	}
+}
+
+func TestMembershipConcurrency(t *testing.T) {
+	m := &membership{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				geth := &ethereum{}
+				m.add(geth)
+				m.remove([]Ethereum{geth})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				for _, geth := range m.snapshot() {
+					m.byName(geth.Name())
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if m.len() != 0 || m.next() != 8*50 {
+		t.Errorf("membership mismatch: have %d fullnodes and %d joined, want 0 and %d", m.len(), m.next(), 8*50)
+	}
+}
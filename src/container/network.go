@@ -23,44 +23,27 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+
+	"go-smilo/src/blockchain/regression/src/common"
 )
 
+var networkCount uint32
+
 const (
 	FirstOctet        = 172
 	SecondOctet       = 17
 	networkNamePrefix = "testnet"
 )
 
-type NetworkManager interface {
-	TryGetFreeSubnet() string
-}
-
-var defaultNetworkManager = newNetworkManager()
-
-func newNetworkManager() *networkManager {
-	return &networkManager{
-		secondOctet: SecondOctet,
-	}
-}
-
-type networkManager struct {
-	mutex       sync.Mutex
-	secondOctet int
-}
-
-func (n *networkManager) TryGetFreeSubnet() string {
-	n.mutex.Lock()
-	defer n.mutex.Unlock()
-	n.secondOctet++
-	return fmt.Sprintf("%d.%d.0.0/16", FirstOctet, n.secondOctet)
-}
-
 type DockerNetwork struct {
 	client  *client.Client
 	id      string
@@ -69,6 +52,18 @@ type DockerNetwork struct {
 
 	mutex   sync.Mutex
 	ipIndex net.IP
+	// seed is the spec seed the addresses were handed out for
+	seed int64
+	// attachedIPs returns the addresses of the containers on the network,
+	// they are skipped since an earlier spec may have leaked them
+	attachedIPs func() (map[string]bool, error)
+
+	partitionMutex sync.Mutex
+	partitioned    []Ethereum
+
+	shapingMutex sync.Mutex
+	shaped       map[string]Ethereum
+	shapedLinks  map[string]map[string]shapedLink
 }
 
 func NewDockerNetwork() (*DockerNetwork, error) {
@@ -78,8 +73,11 @@ func NewDockerNetwork() (*DockerNetwork, error) {
 	}
 
 	network := &DockerNetwork{
-		client: c,
+		client:      c,
+		shaped:      make(map[string]Ethereum),
+		shapedLinks: make(map[string]map[string]shapedLink),
 	}
+	network.attachedIPs = network.inspectIPs
 
 	if err := network.create(); err != nil {
 		return nil, err
@@ -90,14 +88,18 @@ func NewDockerNetwork() (*DockerNetwork, error) {
 
 // create creates a user-defined docker network
 func (n *DockerNetwork) create() error {
-	n.name = fmt.Sprintf("%s%d", networkNamePrefix, time.Now().Unix())
+	// Unique across the processes of a parallel run
+	n.name = fmt.Sprintf("%s%d-%d-%d", networkNamePrefix, time.Now().Unix(), os.Getpid(), atomic.AddUint32(&networkCount, 1))
 
 	var maxTryCount = 15
 	var err error
 	var cResp types.NetworkCreateResponse
 	var subnet string
 	for i := 0; i < maxTryCount && n.id == ""; i++ {
-		subnet = defaultNetworkManager.TryGetFreeSubnet()
+		subnet, err = defaultAllocator.Subnet()
+		if err != nil {
+			return err
+		}
 		ipam := &network.IPAM{
 			Config: []network.IPAMConfig{
 				{
@@ -106,25 +108,57 @@ func (n *DockerNetwork) create() error {
 			},
 		}
 		cResp, err = n.client.NetworkCreate(context.Background(), n.name, types.NetworkCreate{
-			IPAM: ipam,
+			IPAM:   ipam,
+			Labels: resourceLabels(),
 		})
 		if err == nil {
 			break
 		}
+		if !isSubnetOverlap(err) {
+			// Other failures, like an unreachable daemon, say nothing of
+			// the subnet
+			if rerr := defaultAllocator.ReleaseSubnet(subnet); rerr != nil {
+				log.Error("Failed to release subnet", "subnet", subnet, "err", rerr)
+			}
+			return err
+		}
+		// The subnet is used outside of the run
+		if rerr := defaultAllocator.RejectSubnet(subnet); rerr != nil {
+			log.Error("Failed to release subnet", "subnet", subnet, "err", rerr)
+		}
 	}
 
 	if err != nil {
 		return err
 	}
 	n.id = cResp.ID
+	return n.setSubnet(subnet)
+}
+
+// isSubnetOverlap tells whether docker refused to create a network because
+// its subnet overlaps with an address space in use, like "Pool overlaps with
+// other one on this address space".
+func isSubnetOverlap(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "overlap")
+}
+
+func (n *DockerNetwork) setSubnet(subnet string) error {
+	var err error
 	_, n.ipv4Net, err = net.ParseCIDR(subnet)
 	if err != nil {
 		return err
 	}
+	n.resetIPAddrs()
+	return nil
+}
+
+// resetIPAddrs hands out the addresses from the start of the subnet again,
+// for the current spec seed.
+func (n *DockerNetwork) resetIPAddrs() {
 	// IP starts with xxx.xxx.0.1
 	// Because xxx.xxx.0.1 is reserved for default Gateway IP
 	n.ipIndex = net.IPv4(n.ipv4Net.IP[0], n.ipv4Net.IP[1], 0, 1)
-	return nil
+	n.seed = common.Seed()
 }
 
 func (n *DockerNetwork) ID() string {
@@ -140,29 +174,71 @@ func (n *DockerNetwork) Subnet() string {
 }
 
 func (n *DockerNetwork) Remove() error {
-	return n.client.NetworkRemove(context.Background(), n.id)
+	if err := n.client.NetworkRemove(context.Background(), n.id); err != nil {
+		return err
+	}
+	return defaultAllocator.ReleaseSubnet(n.Subnet())
 }
 
+// GetFreeIPAddrs returns the next num addresses of the subnet. A network
+// shared by the specs of a suite starts again from the first address when
+// the spec seed changes, so a spec gets the same addresses whichever specs
+// ran before it, unless containers of an earlier spec still hold them.
 func (n *DockerNetwork) GetFreeIPAddrs(num int) ([]net.IP, error) {
 	n.mutex.Lock()
 	defer n.mutex.Unlock()
 
+	if n.seed != common.Seed() {
+		n.resetIPAddrs()
+	}
+	attached := make(map[string]bool)
+	if n.attachedIPs != nil {
+		var err error
+		if attached, err = n.attachedIPs(); err != nil {
+			return nil, err
+		}
+	}
+
 	ips := make([]net.IP, 0)
-	for len(ips) < num && n.ipv4Net.Contains(n.ipIndex) {
-		ip := dupIP(n.ipIndex)
+	ipIndex := n.ipIndex
+	for len(ips) < num {
+		ip := dupIP(ipIndex)
 		for j := len(ip) - 1; j >= 0; j-- {
 			ip[j]++
 			if ip[j] > 0 {
 				break
 			}
 		}
-		n.ipIndex = ip
+		if !n.ipv4Net.Contains(ip) {
+			break
+		}
+		ipIndex = ip
+		if attached[ip.String()] {
+			continue
+		}
 		ips = append(ips, ip)
 	}
 
 	if len(ips) != num {
 		return nil, errors.New("insufficient IP address.")
 	}
+	// Only consume the addresses if the request can be fulfilled
+	n.ipIndex = ipIndex
+	return ips, nil
+}
+
+// inspectIPs returns the addresses of the containers attached to the network.
+func (n *DockerNetwork) inspectIPs() (map[string]bool, error) {
+	resource, err := n.client.NetworkInspect(context.Background(), n.id, types.NetworkInspectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	ips := make(map[string]bool)
+	for _, endpoint := range resource.Containers {
+		if ip, _, err := net.ParseCIDR(endpoint.IPv4Address); err == nil {
+			ips[ip.String()] = true
+		}
+	}
 	return ips, nil
 }
 
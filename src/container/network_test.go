@@ -0,0 +1,217 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-smilo/src/blockchain/regression/src/common"
+)
+
+func newTestNetwork(t *testing.T, subnet string) *DockerNetwork {
+	network := &DockerNetwork{
+		name:        "regression-test",
+		shaped:      make(map[string]Ethereum),
+		shapedLinks: make(map[string]map[string]shapedLink),
+	}
+	if err := network.setSubnet(subnet); err != nil {
+		t.Fatal(err)
+	}
+	return network
+}
+
+func TestGetFreeIPAddrs(t *testing.T) {
+	network := newTestNetwork(t, "172.19.0.0/16")
+
+	ips, err := network.GetFreeIPAddrs(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"172.19.0.2", "172.19.0.3", "172.19.0.4"}
+	for i, ip := range ips {
+		if ip.String() != expected[i] {
+			t.Errorf("ip mismatch: have %v, want %v", ip, expected[i])
+		}
+	}
+
+	ips, err = network.GetFreeIPAddrs(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ips[0].String() != "172.19.0.5" {
+		t.Errorf("ip mismatch: have %v, want %v", ips[0], "172.19.0.5")
+	}
+}
+
+func TestGetFreeIPAddrsInsufficient(t *testing.T) {
+	network := newTestNetwork(t, "172.19.0.0/30")
+
+	if _, err := network.GetFreeIPAddrs(3); err == nil {
+		t.Error("expected an error when the subnet is exhausted")
+	}
+	ips, err := network.GetFreeIPAddrs(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ips[0].String() != "172.19.0.2" {
+		t.Errorf("ip mismatch: have %v, want %v", ips[0], "172.19.0.2")
+	}
+}
+
+func TestGetFreeIPAddrsPerSeed(t *testing.T) {
+	defer common.SetSeed(common.Seed())
+	network := newTestNetwork(t, "172.19.0.0/16")
+
+	if _, err := network.GetFreeIPAddrs(3); err != nil {
+		t.Fatal(err)
+	}
+	// A new spec seed starts from the first address again
+	common.SetSeed(common.Seed() + 1)
+	ips, err := network.GetFreeIPAddrs(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ips[0].String() != "172.19.0.2" {
+		t.Errorf("ip mismatch: have %v, want %v", ips[0], "172.19.0.2")
+	}
+}
+
+func TestGetFreeIPAddrsSkipsAttached(t *testing.T) {
+	network := newTestNetwork(t, "172.19.0.0/16")
+	// A container leaked by an earlier spec holds the second address
+	network.attachedIPs = func() (map[string]bool, error) {
+		return map[string]bool{"172.19.0.3": true}, nil
+	}
+
+	ips, err := network.GetFreeIPAddrs(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ips[0].String() != "172.19.0.2" || ips[1].String() != "172.19.0.4" {
+		t.Errorf("ips mismatch: have %v, want [172.19.0.2 172.19.0.4]", ips)
+	}
+}
+
+func TestIsSubnetOverlap(t *testing.T) {
+	if !isSubnetOverlap(errors.New("Error response from daemon: Pool overlaps with other one on this address space")) {
+		t.Error("overlapping pool not detected")
+	}
+	if isSubnetOverlap(errors.New("Cannot connect to the Docker daemon at unix:///var/run/docker.sock")) {
+		t.Error("unreachable daemon taken for an overlap")
+	}
+}
+
+func TestUnshapeClearsBothEnds(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 3)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(nil); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	network := bc.dockerNetwork
+	fullnodes := bc.Fullnodes()
+	shape := LinkShape{Delay: 100 * time.Millisecond}
+	if err := network.ShapeLink(fullnodes[0], fullnodes[1], shape); err != nil {
+		t.Fatal(err)
+	}
+	if err := network.ShapeLink(fullnodes[2], fullnodes[1], shape); err != nil {
+		t.Fatal(err)
+	}
+
+	// The links of the other fullnodes towards fullnode 0 go away with it
+	if err := network.Unshape(fullnodes[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := network.shaped[fullnodes[0].ContainerID()]; ok {
+		t.Error("fullnode 0 is still shaped")
+	}
+	links := network.shapedLinks[fullnodes[1].ContainerID()]
+	if _, ok := links[fullnodes[0].IP()]; ok || len(links) != 1 {
+		t.Errorf("links of fullnode 1 mismatch: have %v", links)
+	}
+	if links[fullnodes[2].IP()].band != shapingFirstBand {
+		t.Errorf("band mismatch: have %d, want %d", links[fullnodes[2].IP()].band, shapingFirstBand)
+	}
+	c, err := rt.container(fullnodes[1].ContainerID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt.mutex.Lock()
+	last := strings.Join(c.execs[len(c.execs)-1], " ")
+	rt.mutex.Unlock()
+	if strings.Contains(last, fullnodes[0].IP()) || !strings.Contains(last, fullnodes[2].IP()) {
+		t.Errorf("fullnode 1 was reshaped with %q", last)
+	}
+
+	// Fullnode 2 has no shaped link left once fullnode 1 is restored
+	if err := network.Unshape(fullnodes[1]); err != nil {
+		t.Fatal(err)
+	}
+	if len(network.shaped) != 0 || len(network.shapedLinks) != 0 {
+		t.Errorf("shaping left: %d nodes, %d nodes with links", len(network.shaped), len(network.shapedLinks))
+	}
+}
+
+func TestShapeLinkUndoesFirstDirection(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 2)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(nil); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	// Fullnode 1 cannot be shaped once its container is gone
+	network := bc.dockerNetwork
+	fullnodes := bc.Fullnodes()
+	if err := rt.Remove(fullnodes[1].ContainerID()); err != nil {
+		t.Fatal(err)
+	}
+	if err := network.ShapeLink(fullnodes[0], fullnodes[1], LinkShape{Delay: time.Second}); err == nil {
+		t.Fatal("expected an error shaping fullnode 1")
+	}
+	if len(network.shaped) != 0 || len(network.shapedLinks) != 0 {
+		t.Errorf("shaping left: %d nodes, %d nodes with links", len(network.shaped), len(network.shapedLinks))
+	}
+}
+
+func TestPartitionRejectsEmptyGroups(t *testing.T) {
+	bc, _ := newTestBlockchain(t, 2)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(nil); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	network := bc.dockerNetwork
+	fullnodes := bc.Fullnodes()
+	if err := network.Partition(fullnodes, nil); err == nil {
+		t.Error("expected an error for an empty group")
+	}
+	if err := network.Partition(fullnodes[:1], []Ethereum{}, fullnodes[1:]); err == nil {
+		t.Error("expected an error for an empty group")
+	}
+	if len(network.partitioned) != 0 {
+		t.Errorf("nodes were partitioned: %d", len(network.partitioned))
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	ethtypes "go-smilo/src/blockchain/smilobft/core/types"
+
+	smilocommon "go-smilo/src/blockchain/regression/src/common"
+)
+
+const observerCheckDelay = time.Second
+
+// AddObservers starts num nodes which sync the chain from the fullnodes but do
+// not mine and are never proposed as fullnodes. Every observer dials all the
+// fullnodes, it is returned once it is connected to them and has the head the
+// fullnodes had when it started. The options are applied after the ones of
+// the fullnodes. The lock is only held while the observers are started, so
+// fullnodes can be added or removed while they sync.
+func (bc *blockchain) AddObservers(num int, options ...Option) ([]Ethereum, error) {
+	observers, err := bc.startObservers(num, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fullnodes added meanwhile are dialled by connectObservers, removed
+	// ones are not waited for
+	deadline := time.Now().Add(rejoinTimeout)
+	for _, v := range observers {
+		for {
+			err := v.WaitForPeersConnected(bc.members.len(), observerCheckDelay)
+			if err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				return nil, fmt.Errorf("observer %s is not connected to the fullnodes: %v", v.Name(), err)
+			}
+		}
+	}
+	if err := waitForCaughtUp(observers, bc.members.snapshot(), rejoinTimeout); err != nil {
+		return nil, err
+	}
+	return observers, nil
+}
+
+// startObservers sets up and starts the observers and makes them dial the
+// fullnodes.
+func (bc *blockchain) startObservers(num int, options []Option) ([]Ethereum, error) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	fullnodes := bc.members.snapshot()
+	if len(fullnodes) == 0 {
+		return nil, errors.New("blockchain has no fullnodes")
+	}
+	observers, err := bc.setupObservers(num, options)
+	if err != nil {
+		return nil, err
+	}
+	if err := bc.start(observers); err != nil {
+		return nil, err
+	}
+	for _, v := range observers {
+		if err := dialAll(v, fullnodes); err != nil {
+			return nil, err
+		}
+	}
+	return observers, nil
+}
+
+func (bc *blockchain) Observers() []Ethereum {
+	return bc.observers.snapshot()
+}
+
+func (bc *blockchain) WaitForObserversCaughtUp(timeout time.Duration) error {
+	return waitForCaughtUp(bc.observers.snapshot(), bc.members.snapshot(), timeout)
+}
+
+// waitForCaughtUp waits until every observer has the highest head of the
+// fullnodes at the time of the call, with the same hash.
+func waitForCaughtUp(observers []Ethereum, fullnodes []Ethereum, timeout time.Duration) error {
+	if len(observers) == 0 {
+		return nil
+	}
+	head, err := highestHead(fullnodes)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for _, v := range observers {
+		for {
+			ok, err := hasHeader(v, head)
+			if err != nil {
+				return err
+			}
+			if ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("observer %s has not caught up to block %d %s", v.Name(), head.Number, head.Hash().Hex())
+			}
+			<-time.After(observerCheckDelay)
+		}
+	}
+	return nil
+}
+
+// highestHead returns the highest head of the fullnodes.
+func highestHead(fullnodes []Ethereum) (*ethtypes.Header, error) {
+	var head *ethtypes.Header
+	for _, v := range fullnodes {
+		cli := v.NewClient()
+		if cli == nil {
+			return nil, errors.New("failed to retrieve client")
+		}
+		h, err := cli.HeaderByNumber(context.Background(), nil)
+		cli.Close()
+		if err != nil {
+			return nil, err
+		}
+		if head == nil || h.Number.Cmp(head.Number) > 0 {
+			head = h
+		}
+	}
+	return head, nil
+}
+
+// hasHeader reports whether the node has the header in its chain. Errors of
+// a node which is still starting count as not having it.
+func hasHeader(geth Ethereum, header *ethtypes.Header) (bool, error) {
+	cli := geth.NewClient()
+	if cli == nil {
+		return false, nil
+	}
+	defer cli.Close()
+
+	h, err := cli.HeaderByNumber(context.Background(), new(big.Int).Set(header.Number))
+	if err != nil || h == nil {
+		return false, nil
+	}
+	if h.Hash() != header.Hash() {
+		return false, fmt.Errorf("observer %s has block %d %s instead of %s", geth.Name(), header.Number, h.Hash().Hex(), header.Hash().Hex())
+	}
+	return true, nil
+}
+
+// dialAll makes the node dial every fullnode.
+func dialAll(geth Ethereum, fullnodes []Ethereum) error {
+	for _, v := range fullnodes {
+		if err := geth.AddPeer(v.NodeAddress()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// connectObservers makes every observer dial the current fullnodes, fullnodes
+// dialled before are skipped by the node.
+func (bc *blockchain) connectObservers() error {
+	fullnodes := bc.members.snapshot()
+	for _, v := range bc.observers.snapshot() {
+		if err := dialAll(v, fullnodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bc *blockchain) setupObservers(num int, options []Option) ([]Ethereum, error) {
+	ips, err := bc.dockerNetwork.GetFreeIPAddrs(num)
+	if err != nil {
+		return nil, err
+	}
+	keys, _, _ := smilocommon.GenerateKeys(num)
+
+	var observers []Ethereum
+	for i := 0; i < num; i++ {
+		index := bc.observers.next()
+
+		var opts []Option
+		opts = append(opts, bc.opts...)
+		opts = append(opts, options...)
+		opts = append(opts, NoMining())
+
+		dataDir, err := smilocommon.GenerateRandomDir()
+		if err != nil {
+			log.Error("Failed to create data dir", "dir", dataDir, "err", err)
+			return nil, err
+		}
+		opts = append(opts, HostDataDir(dataDir))
+		opts = append(opts, HostWebSocketPort(freePort()))
+		opts = append(opts, Key(keys[i]))
+		opts = append(opts, HostIP(ips[i]))
+		opts = append(opts, NodeName(observerName(index)))
+
+		if bc.isSmilo {
+			ct := bc.vaultNetwork.GetVault(index % bc.vaultNetwork.NumOfVaults())
+			env := fmt.Sprintf("PRIVATE_CONFIG=%s", ct.ConfigPath())
+			opts = append(opts, DockerEnv([]string{env}))
+			opts = append(opts, DockerBinds(ct.Binds()))
+		}
+
+		geth := NewEthereum(
+			bc.runtime,
+			opts...,
+		)
+
+		err = geth.Init(bc.genesisFile)
+		if err != nil {
+			geth.logger().Error("Failed to init genesis", "file", bc.genesisFile, "err", err)
+			return nil, err
+		}
+
+		bc.observers.add(geth)
+		observers = append(observers, geth)
+	}
+	return observers, nil
+}
@@ -55,6 +55,14 @@ func HostName(hostName string) Option {
 	}
 }
 
+// NodeName is the name of the fullnode in logs and artifacts, it defaults to
+// geth-<host name or ip>.
+func NodeName(name string) Option {
+	return func(eth *ethereum) {
+		eth.name = name
+	}
+}
+
 func HostDataDir(path string) Option {
 	return func(eth *ethereum) {
 		eth.dataDir = path
@@ -170,6 +178,25 @@ func Mine() Option {
 	}
 }
 
+// NoMining drops the mining and account unlocking flags of the options before
+// it, for nodes which only sync the chain.
+func NoMining() Option {
+	return func(eth *ethereum) {
+		var flags []string
+		for i := 0; i < len(eth.flags); i++ {
+			switch eth.flags[i] {
+			case "--" + utils.MiningEnabledFlag.Name:
+			case "--" + utils.UnlockedAccountFlag.Name:
+				// and its value
+				i++
+			default:
+				flags = append(flags, eth.flags[i])
+			}
+		}
+		eth.flags = flags
+	}
+}
+
 func NAT(nat string) Option {
 	return func(eth *ethereum) {
 		eth.flags = append(eth.flags, "--"+utils.NATFlag.Name)
@@ -289,6 +316,22 @@ func FaultyMode(mode int) Option {
 	}
 }
 
+// RequestTimeout is the timeout of a Sport round in milliseconds.
+func RequestTimeout(timeout uint64) Option {
+	return func(eth *ethereum) {
+		eth.flags = append(eth.flags, "--smilobft.requesttimeout")
+		eth.flags = append(eth.flags, fmt.Sprintf("%d", timeout))
+	}
+}
+
+// BlockPeriod is the minimum time between two blocks in seconds.
+func BlockPeriod(period uint64) Option {
+	return func(eth *ethereum) {
+		eth.flags = append(eth.flags, "--smilobft.blockperiod")
+		eth.flags = append(eth.flags, fmt.Sprintf("%d", period))
+	}
+}
+
 func SyncMode(mode string) Option {
 	return func(eth *ethereum) {
 		eth.flags = append(eth.flags, "--"+utils.SyncModeFlag.Name)
@@ -0,0 +1,113 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const partitionChain = "regression-partition"
+
+var ErrInvalidPartition = errors.New("invalid partition")
+
+// Partition splits the given fullnodes into at least two non empty isolated
+// groups. Nodes can reach the nodes of their own group only; nodes which are
+// not part of any group are left untouched. Any previous partition is healed
+// first.
+func (n *DockerNetwork) Partition(groups ...[]Ethereum) error {
+	if len(groups) < 2 {
+		return ErrInvalidPartition
+	}
+
+	seen := make(map[string]int)
+	for i, group := range groups {
+		if len(group) == 0 {
+			return fmt.Errorf("%v: group %d is empty", ErrInvalidPartition, i)
+		}
+		for _, eth := range group {
+			if _, ok := seen[eth.ContainerID()]; ok {
+				return fmt.Errorf("%v: node %s is in more than one group", ErrInvalidPartition, eth.IP())
+			}
+			seen[eth.ContainerID()] = i
+		}
+	}
+
+	if err := n.Heal(); err != nil {
+		return err
+	}
+
+	n.partitionMutex.Lock()
+	defer n.partitionMutex.Unlock()
+
+	for i, group := range groups {
+		for _, eth := range group {
+			var others []string
+			for j, otherGroup := range groups {
+				if i == j {
+					continue
+				}
+				for _, other := range otherGroup {
+					others = append(others, other.IP())
+				}
+			}
+
+			n.partitioned = append(n.partitioned, eth)
+			if err := eth.Exec("sh", "-c", isolateScript(others)); err != nil {
+				nodeLogger(eth).Error("Failed to partition node", "err", err)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Heal removes the partition set up by Partition so that every node can
+// reach every other node again.
+func (n *DockerNetwork) Heal() error {
+	n.partitionMutex.Lock()
+	defer n.partitionMutex.Unlock()
+
+	for len(n.partitioned) > 0 {
+		eth := n.partitioned[0]
+		if err := eth.Exec("sh", "-c", healScript()); err != nil {
+			nodeLogger(eth).Error("Failed to heal node", "err", err)
+			return err
+		}
+		n.partitioned = n.partitioned[1:]
+	}
+	return nil
+}
+
+func isolateScript(ips []string) string {
+	cmds := []string{
+		fmt.Sprintf("iptables -N %s 2>/dev/null", partitionChain),
+		fmt.Sprintf("(iptables -C INPUT -j %[1]s 2>/dev/null || iptables -I INPUT -j %[1]s)", partitionChain),
+		fmt.Sprintf("(iptables -C OUTPUT -j %[1]s 2>/dev/null || iptables -I OUTPUT -j %[1]s)", partitionChain),
+	}
+	for _, ip := range ips {
+		cmds = append(cmds, fmt.Sprintf("iptables -A %s -s %s -j DROP", partitionChain, ip))
+		cmds = append(cmds, fmt.Sprintf("iptables -A %s -d %s -j DROP", partitionChain, ip))
+	}
+	// Creating the chain fails if it already exists, so its exit code is ignored.
+	return cmds[0] + "; " + strings.Join(cmds[1:], " && ")
+}
+
+func healScript() string {
+	return fmt.Sprintf("iptables -F %s 2>/dev/null || true", partitionChain)
+}
@@ -0,0 +1,266 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Topology is a peer graph. Peers are connected in both directions, so a
+// node only needs to dial one side of each link.
+type Topology interface {
+	// Peers returns the indexes of the nodes the node at idx dials in a
+	// network of n nodes.
+	Peers(idx, n int) []int
+	// Validate reports whether the topology can be built with n nodes.
+	Validate(n int) error
+}
+
+// Neighbours returns the sorted indexes of the nodes linked to the node at idx,
+// whichever side dials.
+func Neighbours(t Topology, idx, n int) []int {
+	set := make(map[int]bool)
+	for _, p := range t.Peers(idx, n) {
+		set[p] = true
+	}
+	for i := 0; i < n; i++ {
+		if i == idx {
+			continue
+		}
+		for _, p := range t.Peers(i, n) {
+			if p == idx {
+				set[i] = true
+			}
+		}
+	}
+
+	var result []int
+	for p := range set {
+		result = append(result, p)
+	}
+	sort.Ints(result)
+	return result
+}
+
+type fullMesh struct{}
+
+// FullMesh connects every node to all the others.
+func FullMesh() Topology {
+	return fullMesh{}
+}
+
+func (fullMesh) Peers(idx, n int) []int {
+	var peers []int
+	for i := 0; i < n; i++ {
+		if i != idx {
+			peers = append(peers, i)
+		}
+	}
+	return peers
+}
+
+func (fullMesh) Validate(n int) error {
+	return nil
+}
+
+type ring struct{}
+
+// Ring connects every node to the next one, the last to the first.
+func Ring() Topology {
+	return ring{}
+}
+
+func (ring) Peers(idx, n int) []int {
+	if n < 2 {
+		return nil
+	}
+	return []int{(idx + 1) % n}
+}
+
+func (ring) Validate(n int) error {
+	return nil
+}
+
+type line struct{}
+
+// Line is a ring without the link from the last node to the first.
+func Line() Topology {
+	return line{}
+}
+
+func (line) Peers(idx, n int) []int {
+	if idx+1 >= n {
+		return nil
+	}
+	return []int{idx + 1}
+}
+
+func (line) Validate(n int) error {
+	return nil
+}
+
+type star struct {
+	center int
+}
+
+// Star connects every node to the node at center only.
+func Star(center int) Topology {
+	return star{center: center}
+}
+
+func (t star) Peers(idx, n int) []int {
+	if idx == t.center {
+		return nil
+	}
+	return []int{t.center}
+}
+
+func (t star) Validate(n int) error {
+	if t.center < 0 || t.center >= n {
+		return fmt.Errorf("star center %d out of %d nodes", t.center, n)
+	}
+	return nil
+}
+
+type tree struct {
+	degree int
+}
+
+// Tree connects every node to its parent in a complete tree of the given
+// degree rooted at node 0.
+func Tree(degree int) Topology {
+	return tree{degree: degree}
+}
+
+func (t tree) Peers(idx, n int) []int {
+	if idx == 0 {
+		return nil
+	}
+	return []int{(idx - 1) / t.degree}
+}
+
+func (t tree) Validate(n int) error {
+	if t.degree < 1 {
+		return fmt.Errorf("invalid tree degree %d", t.degree)
+	}
+	return nil
+}
+
+type randomRegular struct {
+	degree int
+	seed   int64
+}
+
+// RandomRegular connects every node to degree random others. The graph only
+// depends on the seed and the number of nodes.
+func RandomRegular(degree int, seed int64) Topology {
+	return randomRegular{degree: degree, seed: seed}
+}
+
+func (t randomRegular) Peers(idx, n int) []int {
+	graph := t.graph(n)
+	if graph == nil {
+		return nil
+	}
+	var peers []int
+	for _, p := range graph[idx] {
+		// The lower index dials
+		if p > idx {
+			peers = append(peers, p)
+		}
+	}
+	return peers
+}
+
+func (t randomRegular) Validate(n int) error {
+	if t.degree < 1 || t.degree >= n || n*t.degree%2 != 0 {
+		return fmt.Errorf("no %d-regular graph of %d nodes", t.degree, n)
+	}
+	if t.graph(n) == nil {
+		return fmt.Errorf("failed to generate a %d-regular graph of %d nodes", t.degree, n)
+	}
+	return nil
+}
+
+// graph pairs the degree stubs of each node at random, it retries until no
+// self loops or parallel links are left.
+func (t randomRegular) graph(n int) [][]int {
+	const maxTryCount = 1000
+
+	if t.degree < 1 || t.degree >= n || n*t.degree%2 != 0 {
+		return nil
+	}
+	r := rand.New(rand.NewSource(t.seed))
+	for try := 0; try < maxTryCount; try++ {
+		stubs := make([]int, 0, n*t.degree)
+		for i := 0; i < n; i++ {
+			for j := 0; j < t.degree; j++ {
+				stubs = append(stubs, i)
+			}
+		}
+		r.Shuffle(len(stubs), func(i, j int) {
+			stubs[i], stubs[j] = stubs[j], stubs[i]
+		})
+
+		graph := make([][]int, n)
+		links := make(map[[2]int]bool)
+		ok := true
+		for i := 0; i < len(stubs); i += 2 {
+			a, b := stubs[i], stubs[i+1]
+			if a > b {
+				a, b = b, a
+			}
+			if a == b || links[[2]int{a, b}] {
+				ok = false
+				break
+			}
+			links[[2]int{a, b}] = true
+			graph[a] = append(graph[a], b)
+			graph[b] = append(graph[b], a)
+		}
+		if ok {
+			return graph
+		}
+	}
+	return nil
+}
+
+// AdjacencyList lists the indexes of the nodes each node dials.
+type AdjacencyList [][]int
+
+func (t AdjacencyList) Peers(idx, n int) []int {
+	if idx >= len(t) {
+		return nil
+	}
+	return t[idx]
+}
+
+func (t AdjacencyList) Validate(n int) error {
+	if len(t) != n {
+		return fmt.Errorf("adjacency has %d entries for %d nodes", len(t), n)
+	}
+	for i, peers := range t {
+		for _, p := range peers {
+			if p < 0 || p >= n || p == i {
+				return fmt.Errorf("invalid peer %d of node %d", p, i)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNeighbours(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology Topology
+		expected [][]int
+	}{
+		{"mesh", FullMesh(), [][]int{{1, 2, 3}, {0, 2, 3}, {0, 1, 3}, {0, 1, 2}}},
+		{"ring", Ring(), [][]int{{1, 3}, {0, 2}, {1, 3}, {0, 2}}},
+		{"line", Line(), [][]int{{1}, {0, 2}, {1, 3}, {2}}},
+		{"star", Star(1), [][]int{{1}, {0, 2, 3}, {1}, {1}}},
+		{"tree", Tree(2), [][]int{{1, 2}, {0, 3}, {0}, {1}}},
+		{"adjacency", AdjacencyList{{1}, {}, {1}, {0}}, [][]int{{1, 3}, {0, 2}, {1}, {0}}},
+	}
+	for _, test := range tests {
+		if err := test.topology.Validate(4); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		for idx, expected := range test.expected {
+			if have := Neighbours(test.topology, idx, 4); !reflect.DeepEqual(have, expected) {
+				t.Errorf("%s: neighbours of %d mismatch: have %v, want %v", test.name, idx, have, expected)
+			}
+		}
+	}
+}
+
+func TestRandomRegular(t *testing.T) {
+	const n, degree = 10, 3
+
+	topology := RandomRegular(degree, 42)
+	if err := topology.Validate(n); err != nil {
+		t.Fatal(err)
+	}
+	for idx := 0; idx < n; idx++ {
+		neighbours := Neighbours(topology, idx, n)
+		if len(neighbours) != degree {
+			t.Errorf("node %d has %d neighbours, want %d", idx, len(neighbours), degree)
+		}
+		if !reflect.DeepEqual(neighbours, Neighbours(RandomRegular(degree, 42), idx, n)) {
+			t.Errorf("node %d neighbours differ for the same seed", idx)
+		}
+	}
+
+	if err := RandomRegular(3, 42).Validate(5); err == nil {
+		t.Error("expected an error for an odd number of stubs")
+	}
+}
+
+func TestTopologyValidate(t *testing.T) {
+	invalid := map[string]Topology{
+		"star":      Star(4),
+		"tree":      Tree(0),
+		"adjacency": AdjacencyList{{1}, {0}},
+		"self peer": AdjacencyList{{0}, {}, {}, {}},
+	}
+	for name, topology := range invalid {
+		if err := topology.Validate(4); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	loopbackIP      = "127.0.0.1"
+	logPollInterval = 200 * time.Millisecond
+)
+
+var signals = map[string]syscall.Signal{
+	"":        syscall.SIGKILL,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGINT":  syscall.SIGINT,
+	"SIGSTOP": syscall.SIGSTOP,
+	"SIGCONT": syscall.SIGCONT,
+}
+
+// processRuntime runs fullnodes and vaults as local processes. Every process
+// listens on the loopback interface, so the IP addresses given in the spec are
+// ignored and ports which cannot be remapped are passed via their flag.
+type processRuntime struct {
+	binaries map[string]string
+	logDir   string
+
+	mutex     sync.Mutex
+	counter   int
+	processes map[string]*process
+}
+
+type process struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	ports map[int]int
+
+	stdoutPath string
+	stderrPath string
+
+	// mutex guards started, the process is started once
+	mutex    sync.Mutex
+	started  bool
+	done     chan struct{}
+	exitCode int
+}
+
+// NewProcessRuntime returns a runtime running the binaries mapped by image
+// repository instead of containers.
+func NewProcessRuntime(binaries map[string]string) (Runtime, error) {
+	logDir, err := ioutil.TempDir("", "regression-process")
+	if err != nil {
+		return nil, err
+	}
+	return &processRuntime{
+		binaries:  binaries,
+		logDir:    logDir,
+		processes: make(map[string]*process),
+	}, nil
+}
+
+func (r *processRuntime) Host() string {
+	return loopbackIP
+}
+
+func (r *processRuntime) EnsureImage(image string, out io.Writer) error {
+	binary, err := r.binary(image)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stat(binary)
+	return err
+}
+
+func (r *processRuntime) Create(spec *Spec) (string, error) {
+	binary, err := r.binary(spec.Image)
+	if err != nil {
+		return "", err
+	}
+
+	r.mutex.Lock()
+	r.counter++
+	id := fmt.Sprintf("process-%d", r.counter)
+	r.mutex.Unlock()
+
+	p := &process{
+		ports:      make(map[int]int),
+		stdoutPath: filepath.Join(r.logDir, id+".stdout"),
+		stderrPath: filepath.Join(r.logDir, id+".stderr"),
+		done:       make(chan struct{}),
+	}
+
+	binds := parseBinds(spec.Binds)
+	var args []string
+	for _, arg := range spec.Cmd {
+		args = append(args, translatePath(arg, binds))
+	}
+	for _, port := range spec.Ports {
+		if port.Flag == "" {
+			continue
+		}
+		hostPort := port.HostPort
+		if hostPort == 0 {
+			hostPort = freePort()
+		}
+		p.ports[port.ContainerPort] = hostPort
+		args = append(args, port.Flag, fmt.Sprintf("%d", hostPort))
+	}
+
+	p.cmd = exec.Command(binary, args...)
+	p.cmd.Env = os.Environ()
+	for _, env := range spec.Env {
+		p.cmd.Env = append(p.cmd.Env, translatePath(env, binds))
+	}
+	if spec.WorkingDir != "" {
+		p.cmd.Dir = translatePath(spec.WorkingDir, binds)
+	}
+	p.stdin, err = p.cmd.StdinPipe()
+	if err != nil {
+		return "", err
+	}
+
+	r.mutex.Lock()
+	r.processes[id] = p
+	r.mutex.Unlock()
+	return id, nil
+}
+
+func (r *processRuntime) Start(id string) error {
+	p, err := r.process(id)
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.started {
+		return fmt.Errorf("process %s already started", id)
+	}
+
+	stdout, err := os.Create(p.stdoutPath)
+	if err != nil {
+		return err
+	}
+	stderr, err := os.Create(p.stderrPath)
+	if err != nil {
+		stdout.Close()
+		return err
+	}
+	p.cmd.Stdout = stdout
+	p.cmd.Stderr = stderr
+
+	if err := p.cmd.Start(); err != nil {
+		stdout.Close()
+		stderr.Close()
+		return err
+	}
+	p.started = true
+
+	go func() {
+		defer close(p.done)
+		defer stdout.Close()
+		defer stderr.Close()
+
+		p.cmd.Wait()
+		if p.cmd.ProcessState != nil {
+			p.exitCode = p.cmd.ProcessState.ExitCode()
+		}
+	}()
+	return nil
+}
+
+func (r *processRuntime) Stop(id string, timeout time.Duration) error {
+	if err := r.Kill(id, "SIGTERM"); err != nil {
+		return err
+	}
+	// A paused process handles the SIGTERM once it is resumed
+	r.Kill(id, "SIGCONT")
+
+	p, _ := r.process(id)
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(timeout):
+		return r.Kill(id, "SIGKILL")
+	}
+}
+
+func (r *processRuntime) Kill(id string, signal string) error {
+	p, err := r.process(id)
+	if err != nil {
+		return err
+	}
+	if !p.running() {
+		return fmt.Errorf("process %s is not running", id)
+	}
+
+	sig, ok := signals[signal]
+	if !ok {
+		return fmt.Errorf("unknown signal %s", signal)
+	}
+	return p.cmd.Process.Signal(sig)
+}
+
+// Pause stops the process with SIGSTOP, it keeps its sockets open but does not
+// answer on them until it is resumed.
+func (r *processRuntime) Pause(id string) error {
+	return r.Kill(id, "SIGSTOP")
+}
+
+func (r *processRuntime) Unpause(id string) error {
+	return r.Kill(id, "SIGCONT")
+}
+
+func (r *processRuntime) Remove(id string) error {
+	p, err := r.process(id)
+	if err != nil {
+		return err
+	}
+	if p.running() {
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+
+	r.mutex.Lock()
+	delete(r.processes, id)
+	r.mutex.Unlock()
+	return nil
+}
+
+func (r *processRuntime) Inspect(id string) (*Status, error) {
+	p, err := r.process(id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{
+		Running: p.running(),
+		IP:      loopbackIP,
+		Ports:   p.ports,
+	}
+	if p.exited() {
+		status.ExitCode = p.exitCode
+	}
+	return status, nil
+}
+
+func (r *processRuntime) Wait(id string, timeout time.Duration) (int, error) {
+	p, err := r.process(id)
+	if err != nil {
+		return -1, err
+	}
+	if !p.isStarted() {
+		return -1, fmt.Errorf("process %s is not started", id)
+	}
+
+	select {
+	case <-p.done:
+		return p.exitCode, nil
+	case <-time.After(timeout):
+		return -1, ErrTimeout
+	}
+}
+
+func (r *processRuntime) Logs(ctx context.Context, id string, follow bool, stdout, stderr io.Writer) error {
+	p, err := r.process(id)
+	if err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.tail(ctx, p.stderrPath, follow, stderr)
+	}()
+	if err := p.tail(ctx, p.stdoutPath, follow, stdout); err != nil {
+		return err
+	}
+	return <-errCh
+}
+
+func (r *processRuntime) Exec(id string, cmd ...string) error {
+	return ErrNotSupported
+}
+
+func (r *processRuntime) Attach(id string, stdin []byte) error {
+	p, err := r.process(id)
+	if err != nil {
+		return err
+	}
+	if !p.running() {
+		return fmt.Errorf("process %s is not running", id)
+	}
+
+	// stdin stays open for later writes, it is closed once the process exits
+	_, err = p.stdin.Write(stdin)
+	return err
+}
+
+// binary returns the binary mapped to the image, or to its repository if the
+// tag of the image has no binary of its own.
+func (r *processRuntime) binary(image string) (string, error) {
+	repository := image
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repository = image[:i]
+	}
+
+	binary := r.binaries[image]
+	if binary == "" {
+		binary = r.binaries[repository]
+	}
+	if binary == "" {
+		return "", fmt.Errorf("no binary for image %s", image)
+	}
+	return binary, nil
+}
+
+func (r *processRuntime) process(id string) (*process, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p, ok := r.processes[id]
+	if !ok {
+		return nil, fmt.Errorf("no such process %s", id)
+	}
+	return p, nil
+}
+
+func (p *process) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *process) isStarted() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.started
+}
+
+func (p *process) running() bool {
+	return p.isStarted() && !p.exited()
+}
+
+// tail copies the log file to w. With follow set it keeps waiting for new
+// output until the process exits or the context is cancelled.
+func (p *process) tail(ctx context.Context, path string, follow bool, w io.Writer) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for {
+		if _, err := io.Copy(w, f); err != nil {
+			return err
+		}
+		if !follow {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-p.done:
+			_, err := io.Copy(w, f)
+			return err
+		case <-time.After(logPollInterval):
+		}
+	}
+}
+
+type bind struct {
+	host      string
+	container string
+}
+
+func parseBinds(binds []string) []bind {
+	var result []bind
+	for _, b := range binds {
+		parts := strings.Split(b, ":")
+		if len(parts) < 2 {
+			continue
+		}
+		result = append(result, bind{host: parts[0], container: parts[1]})
+	}
+	// Prefer the most specific container path.
+	sort.Slice(result, func(i, j int) bool {
+		return len(result[i].container) > len(result[j].container)
+	})
+	return result
+}
+
+// translatePath replaces container paths in s, which may be a path, a
+// "--flag=path" argument or a "KEY=path" environment variable, by host paths.
+func translatePath(s string, binds []bind) string {
+	prefix := ""
+	if i := strings.Index(s, "="); i >= 0 {
+		prefix, s = s[:i+1], s[i+1:]
+	}
+	for _, b := range binds {
+		if s == b.container || strings.HasPrefix(s, b.container+"/") {
+			return prefix + b.host + s[len(b.container):]
+		}
+	}
+	return prefix + s
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestProcessRuntimeAttach(t *testing.T) {
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("cat is not available:", err)
+	}
+	rt, err := NewProcessRuntime(map[string]string{"cat": cat})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := rt.Create(&Spec{Image: "cat:latest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Remove(id)
+	if err := rt.Start(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Start(id); err == nil {
+		t.Error("process was started twice")
+	}
+
+	// Every write reaches the process
+	for _, in := range []string{"first\n", "second\n"} {
+		if err := rt.Attach(id, []byte(in)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-time.After(200 * time.Millisecond)
+	if err := rt.Stop(id, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := rt.Logs(context.Background(), id, false, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "first\nsecond\n" {
+		t.Errorf("output mismatch: have %q, want %q", stdout.String(), "first\nsecond\n")
+	}
+	if err := rt.Attach(id, []byte("third\n")); err == nil {
+		t.Error("wrote to a stopped process")
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	docker "github.com/docker/docker/client"
+
+	"go-smilo/src/blockchain/regression/src/common"
+)
+
+const (
+	// RunLabel labels the docker resources of a run with its id
+	RunLabel = "io.smilo.regression.run"
+	// ProcessLabel labels the docker resources with the id of the process
+	// which created them
+	ProcessLabel = "io.smilo.regression.process"
+	// CreatedLabel labels the docker resources with their creation time
+	CreatedLabel = "io.smilo.regression.created"
+
+	reapTimeout = 2 * time.Minute
+)
+
+// resourceLabels returns the labels of a docker resource created now.
+func resourceLabels() map[string]string {
+	return map[string]string{
+		RunLabel:     common.RunID(),
+		ProcessLabel: common.ProcessID(),
+		CreatedLabel: strconv.FormatInt(time.Now().Unix(), 10),
+	}
+}
+
+// ReapReport lists the resources removed by a Reaper.
+type ReapReport struct {
+	Containers []string
+	Networks   []string
+	Dirs       []string
+}
+
+// Reaper removes the containers, networks and temp dirs leaked by runs.
+type Reaper struct {
+	client *docker.Client
+}
+
+func NewReaper() (*Reaper, error) {
+	c, err := docker.NewEnvClient()
+	if err != nil {
+		return nil, err
+	}
+	return &Reaper{client: c}, nil
+}
+
+// ReapRun removes the resources of the run with the given id.
+func (r *Reaper) ReapRun(runID string) (*ReapReport, error) {
+	if runID == "" {
+		return nil, fmt.Errorf("run id is required")
+	}
+	return r.reap(RunLabel+"="+runID, runID, time.Time{})
+}
+
+// ReapProcess removes the resources created by the process with the given
+// id, the other processes of a parallel run keep theirs.
+func (r *Reaper) ReapProcess(processID string) (*ReapReport, error) {
+	if processID == "" {
+		return nil, fmt.Errorf("process id is required")
+	}
+	return r.reap(ProcessLabel+"="+processID, processID, time.Time{})
+}
+
+// ReapOlderThan removes the resources of every run created more than age ago.
+func (r *Reaper) ReapOlderThan(age time.Duration) (*ReapReport, error) {
+	return r.reap(RunLabel, "", time.Now().Add(-age))
+}
+
+// reap removes the docker resources matching the label filter and created
+// before the cutoff, if it is set, and then the temp dirs of the run.
+func (r *Reaper) reap(label string, runID string, cutoff time.Time) (*ReapReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), reapTimeout)
+	defer cancel()
+
+	args := filters.NewArgs()
+	args.Add("label", label)
+
+	report := &ReapReport{}
+	var errs []string
+
+	containers, err := r.client.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: args})
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range containers {
+		if !createdBefore(c.Labels, cutoff) {
+			continue
+		}
+		if err := r.client.ContainerRemove(ctx, c.ID, types.ContainerRemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+			errs = append(errs, fmt.Sprintf("container %s: %v", shortID(c.ID), err))
+			continue
+		}
+		report.Containers = append(report.Containers, shortID(c.ID))
+	}
+
+	// Networks can only be removed once their containers are gone
+	networks, err := r.client.NetworkList(ctx, types.NetworkListOptions{Filters: args})
+	if err != nil {
+		return report, err
+	}
+	for _, n := range networks {
+		if !createdBefore(n.Labels, cutoff) {
+			continue
+		}
+		if err := r.client.NetworkRemove(ctx, n.ID); err != nil {
+			errs = append(errs, fmt.Sprintf("network %s: %v", n.Name, err))
+			continue
+		}
+		report.Networks = append(report.Networks, n.Name)
+	}
+
+	dirs, err := reapDirs(runID, cutoff)
+	report.Dirs = dirs
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	for _, c := range report.Containers {
+		log.Info("Reaped container", "container", c)
+	}
+	for _, n := range report.Networks {
+		log.Info("Reaped network", "network", n)
+	}
+	if len(errs) > 0 {
+		return report, fmt.Errorf("failed to reap %s", strings.Join(errs, "; "))
+	}
+	return report, nil
+}
+
+// reapDirs removes the temp dirs of the run, or of all runs if runID is
+// empty, which were last modified before the cutoff if it is set.
+func reapDirs(runID string, cutoff time.Time) ([]string, error) {
+	dirs, err := common.TempDirs(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	var reaped []string
+	var errs []string
+	for _, dir := range dirs {
+		if !cutoff.IsZero() {
+			info, err := os.Stat(dir)
+			if err != nil || info.ModTime().After(cutoff) {
+				continue
+			}
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			errs = append(errs, fmt.Sprintf("dir %s: %v", dir, err))
+			continue
+		}
+		reaped = append(reaped, dir)
+	}
+	if len(errs) > 0 {
+		return reaped, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return reaped, nil
+}
+
+func createdBefore(labels map[string]string, cutoff time.Time) bool {
+	if cutoff.IsZero() {
+		return true
+	}
+	created, err := strconv.ParseInt(labels[CreatedLabel], 10, 64)
+	if err != nil {
+		// Reap resources with a run label but no valid creation time
+		return true
+	}
+	return time.Unix(created, 0).Before(cutoff)
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"go-smilo/src/blockchain/regression/src/common"
+)
+
+func TestReapDirs(t *testing.T) {
+	dataDir, err := common.GenerateRandomDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keystoreDir, err := common.GenerateKeystoreDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataDir)
+	defer os.RemoveAll(keystoreDir)
+
+	// Dirs modified after the cutoff are kept
+	reaped, err := reapDirs(common.RunID(), time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reaped) != 0 {
+		t.Errorf("reaped recent dirs %v", reaped)
+	}
+
+	// The dirs of the process are part of the run
+	reaped, err = reapDirs(common.ProcessID(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{dataDir, keystoreDir} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("dir %s was not reaped: %v", dir, err)
+		}
+	}
+	if len(reaped) < 2 {
+		t.Errorf("number of reaped dirs mismatch: have %d, want at least %d", len(reaped), 2)
+	}
+}
+
+func TestCreatedBefore(t *testing.T) {
+	cutoff := time.Unix(1000, 0)
+	tests := []struct {
+		labels map[string]string
+		want   bool
+	}{
+		{map[string]string{CreatedLabel: "999"}, true},
+		{map[string]string{CreatedLabel: "1001"}, false},
+		{map[string]string{}, true},
+	}
+	for _, test := range tests {
+		if have := createdBefore(test.labels, cutoff); have != test.want {
+			t.Errorf("createdBefore(%v) mismatch: have %v, want %v", test.labels, have, test.want)
+		}
+	}
+	if !createdBefore(map[string]string{CreatedLabel: "1001"}, time.Time{}) {
+		t.Errorf("resources should be reaped without a cutoff")
+	}
+}
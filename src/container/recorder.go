@@ -0,0 +1,265 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	ethtypes "go-smilo/src/blockchain/smilobft/core/types"
+)
+
+// BlockRecord is a block as seen by one fullnode.
+type BlockRecord struct {
+	// Node is the index of the fullnode in the recorded list
+	Node      int
+	Number    uint64
+	Hash      common.Hash
+	Timestamp uint64
+	// Received is when the fullnode announced the block
+	Received       time.Time
+	Proposer       common.Address
+	CommittedSeals int
+}
+
+// Fork is a height at which fullnodes reported different blocks.
+type Fork struct {
+	Number uint64
+	// Nodes are the indexes of the fullnodes which reported each hash
+	Nodes map[common.Hash][]int
+}
+
+// ConsensusRecorder records the blocks announced by a set of fullnodes.
+type ConsensusRecorder struct {
+	fullnodes []Ethereum
+
+	mutex   sync.Mutex
+	records []BlockRecord
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewConsensusRecorder(fullnodes []Ethereum) *ConsensusRecorder {
+	return &ConsensusRecorder{fullnodes: fullnodes}
+}
+
+// Start follows the heads of all fullnodes until Stop is called.
+func (r *ConsensusRecorder) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	for i, geth := range r.fullnodes {
+		r.wg.Add(1)
+		go func(i int, geth Ethereum) {
+			defer r.wg.Done()
+			err := geth.FollowHeads(ctx, func(head *ethtypes.Header) (bool, error) {
+				r.record(i, head)
+				return false, nil
+			})
+			if err != nil && err != context.Canceled {
+				nodeLogger(geth).Error("Failed to record heads", "err", err)
+			}
+		}(i, geth)
+	}
+}
+
+func (r *ConsensusRecorder) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+}
+
+func (r *ConsensusRecorder) record(node int, head *ethtypes.Header) {
+	rec := BlockRecord{
+		Node:      node,
+		Number:    head.Number.Uint64(),
+		Hash:      head.Hash(),
+		Timestamp: head.Time.Uint64(),
+		Received:  time.Now(),
+		Proposer:  GetSpeaker(head),
+	}
+	if extra, err := ethtypes.ExtractSportExtra(head); err == nil {
+		rec.CommittedSeals = len(extra.CommittedSeal)
+	}
+	r.add(rec)
+}
+
+func (r *ConsensusRecorder) add(rec BlockRecord) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.records = append(r.records, rec)
+}
+
+// Blocks returns all the records.
+func (r *ConsensusRecorder) Blocks() []BlockRecord {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]BlockRecord{}, r.records...)
+}
+
+// canonical returns the first record of every height, sorted by height.
+func (r *ConsensusRecorder) canonical() []BlockRecord {
+	first := make(map[uint64]BlockRecord)
+	for _, rec := range r.Blocks() {
+		if f, ok := first[rec.Number]; !ok || rec.Received.Before(f.Received) {
+			first[rec.Number] = rec
+		}
+	}
+
+	var result []BlockRecord
+	for _, rec := range first {
+		result = append(result, rec)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Number < result[j].Number
+	})
+	return result
+}
+
+// BlockTimes returns the intervals between consecutive blocks, measured
+// from the first fullnode announcing each of them.
+func (r *ConsensusRecorder) BlockTimes() []time.Duration {
+	var times []time.Duration
+	blocks := r.canonical()
+	for i := 1; i < len(blocks); i++ {
+		if blocks[i].Number != blocks[i-1].Number+1 {
+			continue
+		}
+		times = append(times, blocks[i].Received.Sub(blocks[i-1].Received))
+	}
+	return times
+}
+
+// BlockTimePercentile returns the p-th percentile (0-100) of the block times
+// by nearest rank, 0 if there are none.
+func (r *ConsensusRecorder) BlockTimePercentile(p float64) time.Duration {
+	times := r.BlockTimes()
+	if len(times) == 0 {
+		return 0
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i] < times[j]
+	})
+
+	rank := int(math.Ceil(p / 100 * float64(len(times))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(times) {
+		rank = len(times)
+	}
+	return times[rank-1]
+}
+
+// Forks returns the heights at which fullnodes reported different hashes.
+func (r *ConsensusRecorder) Forks() []Fork {
+	heights := make(map[uint64]map[common.Hash][]int)
+	for _, rec := range r.Blocks() {
+		if heights[rec.Number] == nil {
+			heights[rec.Number] = make(map[common.Hash][]int)
+		}
+		heights[rec.Number][rec.Hash] = append(heights[rec.Number][rec.Hash], rec.Node)
+	}
+
+	var forks []Fork
+	for number, nodes := range heights {
+		if len(nodes) > 1 {
+			forks = append(forks, Fork{Number: number, Nodes: nodes})
+		}
+	}
+	sort.Slice(forks, func(i, j int) bool {
+		return forks[i].Number < forks[j].Number
+	})
+	return forks
+}
+
+// ProposerCounts returns how many blocks in [from, to] each fullnode proposed.
+func (r *ConsensusRecorder) ProposerCounts(from, to uint64) map[common.Address]int {
+	counts := make(map[common.Address]int)
+	for _, rec := range r.canonical() {
+		if rec.Number >= from && rec.Number <= to {
+			counts[rec.Proposer]++
+		}
+	}
+	return counts
+}
+
+// MissingProposers returns the validators which proposed no block in
+// [from, to].
+func (r *ConsensusRecorder) MissingProposers(validators []common.Address, from, to uint64) []common.Address {
+	counts := r.ProposerCounts(from, to)
+
+	var missing []common.Address
+	for _, v := range validators {
+		if counts[v] == 0 {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+// RoundChanges estimates the number of round changes from the proposer
+// rotation, assuming the round robin speaker policy: a block proposed k
+// validators after the expected one was agreed on in round k.
+func (r *ConsensusRecorder) RoundChanges(validators []common.Address) int {
+	vals := append([]common.Address{}, validators...)
+	sort.Slice(vals, func(i, j int) bool {
+		return bytes.Compare(vals[i][:], vals[j][:]) < 0
+	})
+	index := make(map[common.Address]int, len(vals))
+	for i, v := range vals {
+		index[v] = i
+	}
+
+	rounds := 0
+	blocks := r.canonical()
+	for i := 1; i < len(blocks); i++ {
+		if blocks[i].Number != blocks[i-1].Number+1 {
+			continue
+		}
+		last, ok1 := index[blocks[i-1].Proposer]
+		cur, ok2 := index[blocks[i].Proposer]
+		if !ok1 || !ok2 {
+			continue
+		}
+		rounds += ((cur-last-1)%len(vals) + len(vals)) % len(vals)
+	}
+	return rounds
+}
+
+// MinCommittedSeals returns the lowest committed seal count of the recorded
+// blocks after the genesis block, -1 if there are none.
+func (r *ConsensusRecorder) MinCommittedSeals() int {
+	min := -1
+	for _, rec := range r.Blocks() {
+		if rec.Number == 0 {
+			continue
+		}
+		if min == -1 || rec.CommittedSeals < min {
+			min = rec.CommittedSeals
+		}
+	}
+	return min
+}
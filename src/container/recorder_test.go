@@ -0,0 +1,88 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestConsensusRecorder(t *testing.T) {
+	vals := []common.Address{
+		common.HexToAddress("0x01"),
+		common.HexToAddress("0x02"),
+		common.HexToAddress("0x03"),
+		common.HexToAddress("0x04"),
+	}
+	start := time.Now()
+
+	r := NewConsensusRecorder(nil)
+	// Node 0 sees blocks 1 to 5 a second apart, but block 3 takes 3 seconds
+	// and its proposer is one round late.
+	proposers := []int{0, 1, 3, 0, 1}
+	received := []time.Duration{0, time.Second, 4 * time.Second, 5 * time.Second, 6 * time.Second}
+	for i := range proposers {
+		r.add(BlockRecord{
+			Node:           0,
+			Number:         uint64(i + 1),
+			Hash:           common.BigToHash(common.Big1),
+			Received:       start.Add(received[i]),
+			Proposer:       vals[proposers[i]],
+			CommittedSeals: 3,
+		})
+	}
+	// Node 1 sees block 2 later and with another hash
+	r.add(BlockRecord{
+		Node:           1,
+		Number:         2,
+		Hash:           common.BigToHash(common.Big2),
+		Received:       start.Add(2 * time.Second),
+		Proposer:       vals[1],
+		CommittedSeals: 2,
+	})
+
+	// Intervals are 1s, 3s, 1s and 1s
+	if p := r.BlockTimePercentile(50); p != time.Second {
+		t.Errorf("p50 mismatch: have %v, want %v", p, time.Second)
+	}
+	if p := r.BlockTimePercentile(99); p != 3*time.Second {
+		t.Errorf("p99 mismatch: have %v, want %v", p, 3*time.Second)
+	}
+
+	forks := r.Forks()
+	if len(forks) != 1 || forks[0].Number != 2 || len(forks[0].Nodes) != 2 {
+		t.Errorf("forks mismatch: have %+v", forks)
+	}
+
+	counts := r.ProposerCounts(1, 5)
+	if counts[vals[0]] != 2 || counts[vals[1]] != 2 || counts[vals[2]] != 0 || counts[vals[3]] != 1 {
+		t.Errorf("proposer counts mismatch: have %v", counts)
+	}
+	missing := r.MissingProposers(vals, 1, 5)
+	if len(missing) != 1 || missing[0] != vals[2] {
+		t.Errorf("missing proposers mismatch: have %v, want %v", missing, vals[2:3])
+	}
+
+	if rounds := r.RoundChanges(vals); rounds != 1 {
+		t.Errorf("round changes mismatch: have %d, want %d", rounds, 1)
+	}
+	if seals := r.MinCommittedSeals(); seals != 2 {
+		t.Errorf("min committed seals mismatch: have %d, want %d", seals, 2)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	RuntimeEnv       = "REGRESSION_RUNTIME"
+	GethBinaryEnv    = "REGRESSION_GETH_BINARY"
+	GethBinariesEnv  = "REGRESSION_GETH_BINARIES"
+	VaultBinaryEnv   = "REGRESSION_VAULT_BINARY"
+	dockerRuntimeID  = "docker"
+	processRuntimeID = "process"
+)
+
+var ErrNotSupported = errors.New("not supported by runtime")
+
+// Runtime runs the containers of fullnodes and vaults.
+type Runtime interface {
+	// Host returns the address under which published ports are reachable
+	Host() string
+	// EnsureImage makes the given image available, progress is written to out
+	EnsureImage(image string, out io.Writer) error
+
+	// Create creates a container from the spec and returns its id
+	Create(spec *Spec) (string, error)
+	// Start starts a created container
+	Start(id string) error
+	// Stop gracefully stops a container, it is killed after the timeout
+	Stop(id string, timeout time.Duration) error
+	// Kill sends the signal to a running container
+	Kill(id string, signal string) error
+	// Pause freezes all the processes of a running container
+	Pause(id string) error
+	// Unpause resumes a paused container
+	Unpause(id string) error
+	// Remove removes a container, stopping it if necessary
+	Remove(id string) error
+	// Inspect returns the current status of a container
+	Inspect(id string) (*Status, error)
+	// Wait waits for a container to exit and returns its exit code
+	Wait(id string, timeout time.Duration) (int, error)
+
+	// Logs writes the logs of a container to stdout and stderr
+	Logs(ctx context.Context, id string, follow bool, stdout, stderr io.Writer) error
+	// Exec runs a command inside a running container
+	Exec(id string, cmd ...string) error
+	// Attach writes the given input to the stdin of a running container
+	Attach(id string, stdin []byte) error
+}
+
+// Spec describes a container to be created by a Runtime.
+type Spec struct {
+	Hostname   string
+	Image      string
+	Cmd        []string
+	Env        []string
+	Binds      []string
+	WorkingDir string
+	Ports      []PortBinding
+
+	NetworkName string
+	IP          string
+}
+
+// PortBinding is a port a container listens on.
+type PortBinding struct {
+	ContainerPort int
+	// HostPort publishes the port on the host, 0 leaves it unpublished
+	HostPort int
+	// Flag is the command line flag setting the port. Runtimes which cannot
+	// remap ports use it to pass the host port to the command.
+	Flag string
+}
+
+// Status is the state of a container.
+type Status struct {
+	Running  bool
+	ExitCode int
+	// IP is the address other containers reach the container under
+	IP string
+	// Ports maps container ports to the ports other containers reach them under,
+	// ports which are not remapped are missing.
+	Ports map[int]int
+}
+
+// NewRuntime returns the runtime selected by the REGRESSION_RUNTIME environment
+// variable, Docker is used by default. The process runtime runs the binaries
+// given by REGRESSION_GETH_BINARY and REGRESSION_VAULT_BINARY, fullnodes with
+// an image tag listed in REGRESSION_GETH_BINARIES as tag=path,tag=path run the
+// binary of their tag.
+func NewRuntime() (Runtime, error) {
+	switch os.Getenv(RuntimeEnv) {
+	case "", dockerRuntimeID:
+		return NewEnvDockerRuntime()
+	case processRuntimeID:
+		binaries := map[string]string{
+			GetGoSmiloImage(): os.Getenv(GethBinaryEnv),
+			GetVaultImage():   os.Getenv(VaultBinaryEnv),
+		}
+		if tagged := os.Getenv(GethBinariesEnv); tagged != "" {
+			for _, entry := range strings.Split(tagged, ",") {
+				parts := strings.SplitN(entry, "=", 2)
+				if len(parts) != 2 {
+					return nil, fmt.Errorf("invalid %s entry %s", GethBinariesEnv, entry)
+				}
+				binaries[GetGoSmiloImage()+":"+parts[0]] = parts[1]
+			}
+		}
+		return NewProcessRuntime(binaries)
+	default:
+		return nil, fmt.Errorf("unknown runtime %s", os.Getenv(RuntimeEnv))
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	ethtypes "go-smilo/src/blockchain/smilobft/core/types"
+
+	"go-smilo/src/blockchain/regression/src/client"
+)
+
+// SealViolation is a block committed by fewer than 2F+1 fullnodes.
+type SealViolation struct {
+	Number   uint64
+	Hash     common.Hash
+	Seals    int
+	Required int
+}
+
+// Gap is a height below its head which a fullnode cannot serve, or whose
+// block does not extend the block below it.
+type Gap struct {
+	Node   int
+	Number uint64
+	Reason string
+}
+
+// SafetyReport lists the safety violations found by a SafetyAuditor.
+type SafetyReport struct {
+	// From and To are the audited heights
+	From, To  uint64
+	Divergent []Fork
+	WeakSeals []SealViolation
+	Gaps      []Gap
+	// Unreachable are the indexes of the fullnodes which could not be
+	// audited
+	Unreachable []int
+}
+
+func (r *SafetyReport) Safe() bool {
+	return len(r.Divergent) == 0 && len(r.WeakSeals) == 0 && len(r.Gaps) == 0
+}
+
+// Err returns an error describing the violations, nil if there are none.
+func (r *SafetyReport) Err() error {
+	if r.Safe() {
+		return nil
+	}
+
+	var msgs []string
+	for _, f := range r.Divergent {
+		var hashes []string
+		for hash, nodes := range f.Nodes {
+			hashes = append(hashes, fmt.Sprintf("%s on %v", hash.TerminalString(), nodes))
+		}
+		sort.Strings(hashes)
+		msgs = append(msgs, fmt.Sprintf("divergent block %d: %s", f.Number, strings.Join(hashes, ", ")))
+	}
+	for _, v := range r.WeakSeals {
+		msgs = append(msgs, fmt.Sprintf("block %d has %d committed seals, want %d", v.Number, v.Seals, v.Required))
+	}
+	for _, g := range r.Gaps {
+		msgs = append(msgs, fmt.Sprintf("fullnode %d block %d: %s", g.Node, g.Number, g.Reason))
+	}
+	return fmt.Errorf("%d safety violations between blocks %d and %d: %s", len(msgs), r.From, r.To, strings.Join(msgs, "; "))
+}
+
+// SafetyAuditor walks the chains of a set of fullnodes and checks they agree
+// on every block. Each audit continues from the heights all fullnodes had
+// reached in the previous one, so it can run during a scenario as well as
+// after it.
+type SafetyAuditor struct {
+	fullnodes []Ethereum
+	next      uint64
+	// hashes are the audited block hashes of each fullnode by height
+	hashes map[int]map[uint64]common.Hash
+}
+
+func NewSafetyAuditor(fullnodes []Ethereum) *SafetyAuditor {
+	return &SafetyAuditor{
+		fullnodes: fullnodes,
+		next:      1,
+		hashes:    make(map[int]map[uint64]common.Hash),
+	}
+}
+
+func (a *SafetyAuditor) Audit(ctx context.Context) (*SafetyReport, error) {
+	report := &SafetyReport{From: a.next}
+
+	// One client per fullnode asked for validator sets
+	clients := make(map[int]client.Client)
+	defer func() {
+		for _, cli := range clients {
+			cli.Close()
+		}
+	}()
+
+	heights := make(map[uint64]map[common.Hash][]int)
+	headers := make(map[common.Hash]*ethtypes.Header)
+	lowest := uint64(0)
+	audited := 0
+	for idx, geth := range a.fullnodes {
+		head, err := a.auditNode(ctx, idx, geth, report, heights, headers)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			nodeLogger(geth).Warn("Failed to audit fullnode", "err", err)
+			report.Unreachable = append(report.Unreachable, idx)
+			continue
+		}
+		if audited == 0 || head < lowest {
+			lowest = head
+		}
+		if head > report.To {
+			report.To = head
+		}
+		audited++
+	}
+	if audited == 0 {
+		return nil, fmt.Errorf("no fullnode could be audited")
+	}
+
+	var numbers []uint64
+	for number := range heights {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool {
+		return numbers[i] < numbers[j]
+	})
+
+	for _, number := range numbers {
+		nodes := heights[number]
+		if len(nodes) > 1 {
+			report.Divergent = append(report.Divergent, Fork{Number: number, Nodes: nodes})
+		}
+
+		// The set of a height is asked once, from the lowest fullnode
+		// holding a block there
+		holder := -1
+		for _, holders := range nodes {
+			for _, idx := range holders {
+				if holder == -1 || idx < holder {
+					holder = idx
+				}
+			}
+		}
+		required, err := a.requiredSeals(ctx, clients, holder, number)
+		if err != nil {
+			return nil, err
+		}
+
+		for hash := range nodes {
+			seals := 0
+			if extra, err := ethtypes.ExtractSportExtra(headers[hash]); err == nil {
+				seals = len(extra.CommittedSeal)
+			}
+			if seals < required {
+				report.WeakSeals = append(report.WeakSeals, SealViolation{
+					Number:   number,
+					Hash:     hash,
+					Seals:    seals,
+					Required: required,
+				})
+			}
+		}
+	}
+
+	// Heights above the lowest head may still be missing on some fullnodes
+	if lowest+1 > a.next {
+		a.next = lowest + 1
+	}
+	return report, nil
+}
+
+// auditNode reads the blocks of a fullnode from the next height up to its
+// head, it returns the head height.
+func (a *SafetyAuditor) auditNode(ctx context.Context, idx int, geth Ethereum, report *SafetyReport, heights map[uint64]map[common.Hash][]int, headers map[common.Hash]*ethtypes.Header) (uint64, error) {
+	cli := geth.NewClient()
+	if cli == nil {
+		return 0, fmt.Errorf("failed to retrieve client")
+	}
+	defer cli.Close()
+
+	head, err := cli.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if a.hashes[idx] == nil {
+		a.hashes[idx] = make(map[uint64]common.Hash)
+	}
+	hashes := a.hashes[idx]
+	for number := a.next; number <= head.Uint64(); number++ {
+		header, err := cli.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil || header == nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			report.Gaps = append(report.Gaps, Gap{Node: idx, Number: number, Reason: fmt.Sprintf("missing block: %v", err)})
+			continue
+		}
+
+		hash := header.Hash()
+		if parent, ok := hashes[number-1]; ok && header.ParentHash != parent {
+			report.Gaps = append(report.Gaps, Gap{Node: idx, Number: number, Reason: "parent hash mismatch"})
+		}
+		hashes[number] = hash
+
+		if heights[number] == nil {
+			heights[number] = make(map[common.Hash][]int)
+		}
+		heights[number][hash] = append(heights[number][hash], idx)
+		headers[hash] = header
+	}
+	return head.Uint64(), nil
+}
+
+// requiredSeals returns 2F+1 for the validator set which sealed the block at
+// number, the set after its parent, as reported by the fullnode at idx. The
+// clients are reused across heights.
+func (a *SafetyAuditor) requiredSeals(ctx context.Context, clients map[int]client.Client, idx int, number uint64) (int, error) {
+	cli := clients[idx]
+	if cli == nil {
+		if cli = a.fullnodes[idx].NewClient(); cli == nil {
+			return 0, fmt.Errorf("failed to retrieve client")
+		}
+		clients[idx] = cli
+	}
+
+	vals, err := cli.GetFullnodes(ctx, new(big.Int).SetUint64(number-1))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get fullnodes at block %d: %v", number-1, err)
+	}
+	f := (len(vals) - 1) / 3
+	return 2*f + 1, nil
+}
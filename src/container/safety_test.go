@@ -0,0 +1,194 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"go-smilo/src/blockchain/smilobft/core/types"
+)
+
+// sportExtra returns extra data carrying the given number of committed
+// seals, blocks with different vanity bytes have different hashes.
+func sportExtra(t *testing.T, vanity byte, seals int) []byte {
+	extra := &types.SportExtra{
+		Seal:          make([]byte, types.BFTExtraSeal),
+		CommittedSeal: make([][]byte, seals),
+	}
+	for i := range extra.CommittedSeal {
+		extra.CommittedSeal[i] = make([]byte, types.BFTExtraSeal)
+	}
+	payload, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(bytes.Repeat([]byte{vanity}, types.SportExtraVanity), payload...)
+}
+
+func newAuditedBlockchain(t *testing.T) (*blockchain, []*fakeBackend) {
+	bc, rt := newTestBlockchain(t, 4)
+	if err := bc.Start(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var vals []common.Address
+	for _, v := range bc.Fullnodes() {
+		vals = append(vals, v.Address())
+	}
+	var backends []*fakeBackend
+	for _, v := range bc.Fullnodes() {
+		b := rt.backend(v.IP())
+		b.SetFullnodes(vals)
+		b.SetExtra(func(number uint64) []byte {
+			return sportExtra(t, 0, 3)
+		})
+		backends = append(backends, b)
+	}
+	return bc, backends
+}
+
+func TestSafetyAuditor(t *testing.T) {
+	bc, backends := newAuditedBlockchain(t)
+	defer finalizeTestBlockchain(bc)
+	defer bc.Stop(true)
+
+	for _, b := range backends {
+		b.Mine(3)
+	}
+	auditor := NewSafetyAuditor(bc.Fullnodes())
+	report, err := auditor.Audit(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Err(); err != nil {
+		t.Error(err)
+	}
+	if report.From != 1 || report.To != 3 {
+		t.Errorf("audited heights mismatch: have %d to %d, want 1 to 3", report.From, report.To)
+	}
+
+	// The next audit continues from where all fullnodes were
+	for _, b := range backends {
+		b.Mine(2)
+	}
+	report, err = auditor.Audit(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Err(); err != nil {
+		t.Error(err)
+	}
+	if report.From != 4 || report.To != 5 {
+		t.Errorf("audited heights mismatch: have %d to %d, want 4 to 5", report.From, report.To)
+	}
+}
+
+func TestSafetyAuditorViolations(t *testing.T) {
+	bc, backends := newAuditedBlockchain(t)
+	defer finalizeTestBlockchain(bc)
+	defer bc.Stop(true)
+
+	// Fullnode 3 forks at block 3, block 2 lacks a seal everywhere and
+	// fullnode 1 misses block 2
+	for i, b := range backends {
+		i := i
+		b.SetExtra(func(number uint64) []byte {
+			switch {
+			case number == 2:
+				return sportExtra(t, 0, 2)
+			case number >= 3 && i == 3:
+				return sportExtra(t, 1, 3)
+			}
+			return sportExtra(t, 0, 3)
+		})
+		b.Mine(4)
+	}
+	backends[1].Drop(2)
+
+	report, err := NewSafetyAuditor(bc.Fullnodes()).Audit(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Safe() || report.Err() == nil {
+		t.Fatal("expected safety violations")
+	}
+
+	if len(report.Divergent) != 2 || report.Divergent[0].Number != 3 || report.Divergent[1].Number != 4 {
+		t.Errorf("divergent blocks mismatch: have %+v", report.Divergent)
+	}
+	if len(report.WeakSeals) != 1 || report.WeakSeals[0].Number != 2 || report.WeakSeals[0].Seals != 2 || report.WeakSeals[0].Required != 3 {
+		t.Errorf("weak seals mismatch: have %+v", report.WeakSeals)
+	}
+	if len(report.Gaps) != 1 || report.Gaps[0].Node != 1 || report.Gaps[0].Number != 2 {
+		t.Errorf("gaps mismatch: have %+v", report.Gaps)
+	}
+}
+
+func TestSafetyAuditorParentValidatorSet(t *testing.T) {
+	bc, backends := newAuditedBlockchain(t)
+	defer finalizeTestBlockchain(bc)
+	defer bc.Stop(true)
+
+	// Three fullnodes join with block 2, block 3 is the first one sealed
+	// by the seven of them
+	seven := make([]common.Address, 7)
+	for i := range seven {
+		seven[i] = common.BytesToAddress([]byte{byte(i + 1)})
+	}
+	for _, b := range backends {
+		b.SetFullnodesAt(func(number uint64) []common.Address {
+			if number >= 2 {
+				return seven
+			}
+			return seven[:4]
+		})
+		b.SetExtra(func(number uint64) []byte {
+			if number >= 3 {
+				return sportExtra(t, 0, 5)
+			}
+			return sportExtra(t, 0, 3)
+		})
+		b.Mine(4)
+	}
+
+	report, err := NewSafetyAuditor(bc.Fullnodes()).Audit(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Err(); err != nil {
+		t.Error(err)
+	}
+
+	// Every set is asked once, at the parent height
+	var queried []uint64
+	for _, b := range backends {
+		queried = append(queried, b.Queried()...)
+	}
+	if len(queried) != 4 {
+		t.Fatalf("queried heights mismatch: have %v, want 0 to 3", queried)
+	}
+	for i, number := range queried {
+		if number != uint64(i) {
+			t.Errorf("queried height mismatch: have %d, want %d", number, i)
+		}
+	}
+}
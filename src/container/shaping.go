@@ -0,0 +1,234 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	shapingDevice = "eth0"
+	// The first three bands of the root prio qdisc carry the unshaped traffic,
+	// the remaining ones are handed out to shaped links.
+	shapingBands     = 16
+	shapingFirstBand = 4
+)
+
+var ErrTooManyLinks = errors.New("too many shaped links")
+
+// shapedLink is a shaped link from a node, in its band of the prio qdisc.
+type shapedLink struct {
+	band  int
+	shape LinkShape
+}
+
+// LinkShape describes the netem impairments applied to a link. Zero values
+// disable the corresponding impairment, a shape replaces the previous one of
+// the node or link as a whole.
+type LinkShape struct {
+	Delay  time.Duration
+	Jitter time.Duration
+	// Loss is the packet loss in percent
+	Loss float64
+	// Rate is the bandwidth limit in kbit/s
+	Rate int
+}
+
+func (s LinkShape) netem() string {
+	args := []string{"netem"}
+	if s.Delay > 0 {
+		args = append(args, fmt.Sprintf("delay %dms", s.Delay/time.Millisecond))
+		if s.Jitter > 0 {
+			args = append(args, fmt.Sprintf("%dms", s.Jitter/time.Millisecond))
+		}
+	}
+	if s.Loss > 0 {
+		args = append(args, fmt.Sprintf("loss %g%%", s.Loss))
+	}
+	if s.Rate > 0 {
+		args = append(args, fmt.Sprintf("rate %dkbit", s.Rate))
+	}
+	return strings.Join(args, " ")
+}
+
+// Shape applies the shape to all outgoing traffic of the given node.
+func (n *DockerNetwork) Shape(eth Ethereum, shape LinkShape) error {
+	n.shapingMutex.Lock()
+	defer n.shapingMutex.Unlock()
+
+	// A node-wide shape replaces any per-link shaping.
+	delete(n.shapedLinks, eth.ContainerID())
+	n.shaped[eth.ContainerID()] = eth
+
+	cmd := fmt.Sprintf("tc qdisc replace dev %s root %s", shapingDevice, shape.netem())
+	if err := eth.Exec("sh", "-c", cmd); err != nil {
+		nodeLogger(eth).Error("Failed to shape node", "err", err)
+		return err
+	}
+	return nil
+}
+
+// ShapeLink applies the shape to the traffic between the two given nodes in
+// both directions.
+func (n *DockerNetwork) ShapeLink(a, b Ethereum, shape LinkShape) error {
+	n.shapingMutex.Lock()
+	defer n.shapingMutex.Unlock()
+
+	prev, shaped := n.shapedLinks[a.ContainerID()][b.IP()]
+	if err := n.shapeLink(a, b, shape); err != nil {
+		return err
+	}
+	if err := n.shapeLink(b, a, shape); err != nil {
+		// Both directions or none are shaped
+		var uerr error
+		if shaped {
+			uerr = n.shapeLink(a, b, prev.shape)
+		} else {
+			delete(n.shapedLinks[a.ContainerID()], b.IP())
+			uerr = n.reshape(a)
+		}
+		if uerr != nil {
+			log.Error("Failed to undo shaped link", "src", a.IP(), "dst", b.IP(), "err", uerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// Unshape restores normal links for the given nodes, the links other nodes
+// shaped towards them are restored too. All shaped nodes are restored if no
+// node is given.
+func (n *DockerNetwork) Unshape(eths ...Ethereum) error {
+	n.shapingMutex.Lock()
+	defer n.shapingMutex.Unlock()
+
+	if len(eths) == 0 {
+		for _, eth := range n.shaped {
+			eths = append(eths, eth)
+		}
+	}
+
+	for _, eth := range eths {
+		if _, ok := n.shaped[eth.ContainerID()]; ok {
+			if err := n.clearShaping(eth); err != nil {
+				return err
+			}
+		}
+
+		for id, links := range n.shapedLinks {
+			if _, ok := links[eth.IP()]; !ok {
+				continue
+			}
+			delete(links, eth.IP())
+			if err := n.reshape(n.shaped[id]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// clearShaping removes every shape of the node.
+func (n *DockerNetwork) clearShaping(eth Ethereum) error {
+	cmd := fmt.Sprintf("tc qdisc del dev %s root 2>/dev/null || true", shapingDevice)
+	if err := eth.Exec("sh", "-c", cmd); err != nil {
+		nodeLogger(eth).Error("Failed to unshape node", "err", err)
+		return err
+	}
+	delete(n.shaped, eth.ContainerID())
+	delete(n.shapedLinks, eth.ContainerID())
+	return nil
+}
+
+// reshape applies the remaining shaped links of the node again, in bands
+// following each other so that new links get the next one.
+func (n *DockerNetwork) reshape(src Ethereum) error {
+	links := n.shapedLinks[src.ContainerID()]
+	if len(links) == 0 {
+		return n.clearShaping(src)
+	}
+
+	var dsts []string
+	for dst := range links {
+		dsts = append(dsts, dst)
+	}
+	sort.Slice(dsts, func(i, j int) bool {
+		return links[dsts[i]].band < links[dsts[j]].band
+	})
+
+	cmds := []string{
+		fmt.Sprintf("(tc qdisc del dev %s root 2>/dev/null || true)", shapingDevice),
+		fmt.Sprintf("tc qdisc add dev %s root handle 1: prio bands %d", shapingDevice, shapingBands),
+	}
+	for i, dst := range dsts {
+		link := shapedLink{band: shapingFirstBand + i, shape: links[dst].shape}
+		cmds = append(cmds, link.commands(dst)...)
+		links[dst] = link
+	}
+
+	if err := src.Exec("sh", "-c", strings.Join(cmds, " && ")); err != nil {
+		nodeLogger(src).Error("Failed to reshape node", "err", err)
+		return err
+	}
+	return nil
+}
+
+// commands returns the commands adding the band of the link to dst.
+func (l shapedLink) commands(dst string) []string {
+	return []string{
+		fmt.Sprintf("tc qdisc add dev %s parent 1:%d handle %d0: %s", shapingDevice, l.band, l.band, l.shape.netem()),
+		fmt.Sprintf("tc filter add dev %s protocol ip parent 1:0 prio 1 u32 match ip dst %s/32 flowid 1:%d", shapingDevice, dst, l.band),
+	}
+}
+
+// shapeLink shapes the traffic from src to dst. Every destination gets its own
+// band of a prio qdisc, selected by a u32 filter on the destination address.
+func (n *DockerNetwork) shapeLink(src, dst Ethereum, shape LinkShape) error {
+	links, ok := n.shapedLinks[src.ContainerID()]
+	var cmds []string
+	if !ok {
+		links = make(map[string]shapedLink)
+		cmds = append(cmds, fmt.Sprintf("tc qdisc replace dev %s root handle 1: prio bands %d", shapingDevice, shapingBands))
+	}
+
+	link, ok := links[dst.IP()]
+	if !ok {
+		link.band = shapingFirstBand + len(links)
+		if link.band > shapingBands {
+			return ErrTooManyLinks
+		}
+		link.shape = shape
+		cmds = append(cmds, link.commands(dst.IP())...)
+	} else {
+		link.shape = shape
+		cmds = append(cmds, fmt.Sprintf("tc qdisc change dev %s parent 1:%d handle %d0: %s", shapingDevice, link.band, link.band, shape.netem()))
+	}
+
+	if err := src.Exec("sh", "-c", strings.Join(cmds, " && ")); err != nil {
+		log.Error("Failed to shape link", "src", src.IP(), "dst", dst.IP(), "err", err)
+		return err
+	}
+
+	links[dst.IP()] = link
+	n.shapedLinks[src.ContainerID()] = links
+	n.shaped[src.ContainerID()] = src
+	return nil
+}
@@ -0,0 +1,334 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	smilocommon "go-smilo/src/blockchain/regression/src/common"
+	"go-smilo/src/blockchain/regression/src/genesis"
+)
+
+const (
+	PeersMesh = "mesh"
+	PeersRing = "ring"
+	PeersLine = "line"
+	// PeersStar connects every node to node 0
+	PeersStar = "star"
+)
+
+// TopologySpec describes a test blockchain, it is read from a YAML or JSON
+// file by LoadTopology. For example
+//
+//	smilo: true
+//	genesis:
+//	  gasLimit: 9000000
+//	peers: ring
+//	nodes:
+//	  - count: 3
+//	  - count: 1
+//	    tag: regression_test
+//	    faulty: 1
+type TopologySpec struct {
+	// Image and Tag are the defaults of the nodes
+	Image   string `json:"image" yaml:"image"`
+	Tag     string `json:"tag" yaml:"tag"`
+	Logging bool   `json:"logging" yaml:"logging"`
+
+	// Smilo pairs every fullnode with a vault, fullnode i uses vault
+	// i % Vaults. Vaults defaults to one per fullnode.
+	Smilo  bool `json:"smilo" yaml:"smilo"`
+	Vaults int  `json:"vaults" yaml:"vaults"`
+
+	Genesis GenesisSpec `json:"genesis" yaml:"genesis"`
+
+	// Peers is mesh (default), ring, line or star, it is ignored if
+	// Adjacency is set. Adjacency lists the indexes of the peers each node
+	// dials.
+	Peers     string  `json:"peers" yaml:"peers"`
+	Adjacency [][]int `json:"adjacency" yaml:"adjacency"`
+
+	Nodes []NodeSpec `json:"nodes" yaml:"nodes"`
+}
+
+type GenesisSpec struct {
+	GasLimit      uint64 `json:"gasLimit" yaml:"gasLimit"`
+	ChainID       uint64 `json:"chainId" yaml:"chainId"`
+	Epoch         uint64 `json:"epoch" yaml:"epoch"`
+	SpeakerPolicy uint64 `json:"speakerPolicy" yaml:"speakerPolicy"`
+}
+
+// options returns the genesis options of the fields which are set.
+func (spec GenesisSpec) options() []genesis.Option {
+	var opts []genesis.Option
+	if spec.GasLimit != 0 {
+		opts = append(opts, genesis.GasLimit(spec.GasLimit))
+	}
+	if spec.ChainID != 0 {
+		opts = append(opts, genesis.ChainID(spec.ChainID))
+	}
+	if spec.Epoch != 0 {
+		opts = append(opts, genesis.Epoch(spec.Epoch))
+	}
+	if spec.SpeakerPolicy != 0 {
+		opts = append(opts, genesis.SpeakerPolicy(spec.SpeakerPolicy))
+	}
+	return opts
+}
+
+// NodeSpec is a group of identical fullnodes.
+type NodeSpec struct {
+	Count  int             `json:"count" yaml:"count"`
+	Image  string          `json:"image" yaml:"image"`
+	Tag    string          `json:"tag" yaml:"tag"`
+	Faulty FaultyBehaviour `json:"faulty" yaml:"faulty"`
+}
+
+func ReadTopology(path string) (*TopologySpec, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &TopologySpec{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		// Unknown fields are rejected as with YAML, a typo is not ignored
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(spec)
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(raw, spec)
+	default:
+		return nil, fmt.Errorf("unknown topology format %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse topology %s: %v", path, err)
+	}
+
+	if err := spec.setDefaults(); err != nil {
+		return nil, fmt.Errorf("invalid topology %s: %v", path, err)
+	}
+	return spec, nil
+}
+
+func (spec *TopologySpec) setDefaults() error {
+	if spec.Image == "" {
+		spec.Image = GetGoSmiloImage()
+	}
+	if spec.Tag == "" {
+		spec.Tag = "latest"
+	}
+	if spec.Peers == "" {
+		spec.Peers = PeersMesh
+	}
+	switch spec.Peers {
+	case PeersMesh, PeersRing, PeersLine, PeersStar:
+	default:
+		return fmt.Errorf("unknown peers %s", spec.Peers)
+	}
+
+	total := 0
+	for i := range spec.Nodes {
+		node := &spec.Nodes[i]
+		if node.Count == 0 {
+			node.Count = 1
+		}
+		if node.Count < 0 {
+			return fmt.Errorf("invalid count %d of node group %d", node.Count, i)
+		}
+		if node.Image == "" {
+			node.Image = spec.Image
+		}
+		if node.Tag == "" {
+			node.Tag = spec.Tag
+		}
+		if err := node.Faulty.Validate(); err != nil {
+			return fmt.Errorf("%v of node group %d", err, i)
+		}
+		total += node.Count
+	}
+	if total == 0 {
+		return fmt.Errorf("no nodes")
+	}
+
+	if spec.Smilo && spec.Vaults == 0 {
+		spec.Vaults = total
+	}
+	if !spec.Smilo && spec.Vaults != 0 {
+		return fmt.Errorf("vaults require smilo")
+	}
+
+	return spec.Topology().Validate(total)
+}
+
+// Topology returns the peer graph of the fullnodes.
+func (spec *TopologySpec) Topology() Topology {
+	if spec.Adjacency != nil {
+		return AdjacencyList(spec.Adjacency)
+	}
+	switch spec.Peers {
+	case PeersRing:
+		return Ring()
+	case PeersLine:
+		return Line()
+	case PeersStar:
+		return Star(0)
+	}
+	return FullMesh()
+}
+
+func (spec *TopologySpec) NumOfNodes() int {
+	total := 0
+	for _, node := range spec.Nodes {
+		total += node.Count
+	}
+	return total
+}
+
+// LoadTopology reads a topology file and builds its blockchain on a docker
+// network of its own. The blockchain owns the network and the vaults, they
+// are started, stopped and removed along with the fullnodes.
+func LoadTopology(path string) (Blockchain, error) {
+	spec, err := ReadTopology(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewTopologyBlockchain(spec)
+}
+
+func NewTopologyBlockchain(spec *TopologySpec) (_ *topologyBlockchain, err error) {
+	network, err := NewDockerNetwork()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create docker network %s", err)
+	}
+	bc := &topologyBlockchain{
+		blockchain: &blockchain{dockerNetwork: network, isSmilo: spec.Smilo, topology: spec.Topology()},
+		spec:       spec,
+		network:    network,
+	}
+	defer func() {
+		if err != nil {
+			bc.Finalize()
+		}
+	}()
+
+	bc.runtime, err = NewRuntime()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create container runtime %s", err)
+	}
+
+	if spec.Smilo {
+		bc.vaults, err = NewDefaultVaultNetwork(network, spec.Vaults)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create vault network %s", err)
+		}
+		bc.vaultNetwork = bc.vaults
+	}
+
+	bc.genesisOptions = append(bc.genesisOptions, spec.Genesis.options()...)
+
+	commonOpts := DefaultOptions()
+	commonOpts = append(commonOpts, DockerNetworkName(network.Name()), Logging(spec.Logging))
+	if spec.Smilo {
+		commonOpts = append(commonOpts, IsSmilo(true), NoUSB())
+	}
+	// Fullnodes added later use the default image
+	bc.opts = append(commonOpts, ImageRepository(spec.Image), ImageTag(spec.Tag))
+
+	totalNodes := spec.NumOfNodes()
+	ips, err := network.GetFreeIPAddrs(totalNodes)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get free ip addresses %s", err)
+	}
+
+	//Create accounts
+	bc.generateAccounts(totalNodes)
+
+	keys, _, addrs := smilocommon.GenerateKeys(totalNodes)
+	bc.setupGenesis(addrs)
+
+	offset := 0
+	for i, node := range spec.Nodes {
+		opts := append(commonOpts[:len(commonOpts):len(commonOpts)], ImageRepository(node.Image), ImageTag(node.Tag))
+		opts = append(opts, Faulty(node.Faulty))
+		end := offset + node.Count
+		if err = bc.setupFullnodes(ips[offset:end], keys[offset:end], offset, opts...); err != nil {
+			return nil, fmt.Errorf("Error setting up node group %d %s", i, err)
+		}
+		offset = end
+	}
+	return bc, nil
+}
+
+type topologyBlockchain struct {
+	*blockchain
+
+	spec    *TopologySpec
+	network *DockerNetwork
+	vaults  *vaultNetwork
+}
+
+// Start starts the vaults and the fullnodes, the fullnodes are connected as
+// described by the topology file unless t is set.
+func (bc *topologyBlockchain) Start(t Topology) error {
+	if bc.vaults != nil {
+		if err := bc.vaults.Start(); err != nil {
+			return err
+		}
+	}
+	return bc.blockchain.Start(t)
+}
+
+func (bc *topologyBlockchain) Stop(force bool) error {
+	if err := bc.blockchain.Stop(force); err != nil {
+		return err
+	}
+	if bc.vaults != nil {
+		return bc.vaults.Stop()
+	}
+	return nil
+}
+
+func (bc *topologyBlockchain) CollectArtifacts(dir string) error {
+	err := bc.blockchain.CollectArtifacts(dir)
+	if bc.vaults != nil {
+		if verr := bc.vaults.CollectArtifacts(dir); err == nil {
+			err = verr
+		}
+	}
+	return err
+}
+
+func (bc *topologyBlockchain) Finalize() {
+	if bc.genesisFile != "" {
+		bc.blockchain.Finalize()
+	}
+	if bc.vaults != nil {
+		bc.vaults.Finalize()
+	}
+	if err := bc.network.Remove(); err != nil {
+		log.Error("Failed to remove docker network", "network", bc.network.Name(), "err", err)
+	}
+}
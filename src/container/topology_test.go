@@ -0,0 +1,127 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTopology(t *testing.T, name string, content string) string {
+	dir, err := ioutil.TempDir("", "topology")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadTopologyYAML(t *testing.T) {
+	path := writeTopology(t, "topology.yaml", `
+smilo: true
+genesis:
+  gasLimit: 9000000
+peers: ring
+nodes:
+  - count: 3
+  - tag: regression_test
+    faulty: 1
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	spec, err := ReadTopology(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.NumOfNodes() != 4 {
+		t.Errorf("node count mismatch: have %d, want %d", spec.NumOfNodes(), 4)
+	}
+	if spec.Vaults != 4 {
+		t.Errorf("vault count mismatch: have %d, want %d", spec.Vaults, 4)
+	}
+	if spec.Genesis.GasLimit != 9000000 {
+		t.Errorf("gas limit mismatch: have %d, want %d", spec.Genesis.GasLimit, 9000000)
+	}
+	if spec.Peers != PeersRing {
+		t.Errorf("peers mismatch: have %s, want %s", spec.Peers, PeersRing)
+	}
+
+	faulty := spec.Nodes[1]
+	if faulty.Count != 1 || faulty.Image != GetGoSmiloImage() || faulty.Tag != "regression_test" || faulty.Faulty != RandomFault {
+		t.Errorf("faulty node mismatch: have %+v", faulty)
+	}
+	if spec.Nodes[0].Tag != "latest" {
+		t.Errorf("default tag mismatch: have %s, want %s", spec.Nodes[0].Tag, "latest")
+	}
+}
+
+func TestReadTopologyJSON(t *testing.T) {
+	path := writeTopology(t, "topology.json", `{
+	"image": "go-smilo",
+	"tag": "dev",
+	"adjacency": [[1], [2], [0]],
+	"nodes": [{"count": 3}]
+}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	spec, err := ReadTopology(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Smilo || spec.Vaults != 0 {
+		t.Errorf("unexpected vaults: smilo %v, vaults %d", spec.Smilo, spec.Vaults)
+	}
+	if spec.Nodes[0].Image != "go-smilo" || spec.Nodes[0].Tag != "dev" {
+		t.Errorf("node image mismatch: have %s:%s, want go-smilo:dev", spec.Nodes[0].Image, spec.Nodes[0].Tag)
+	}
+	if len(spec.Adjacency) != 3 {
+		t.Errorf("adjacency mismatch: have %v", spec.Adjacency)
+	}
+}
+
+func TestReadTopologyInvalid(t *testing.T) {
+	topologies := map[string]string{
+		"no nodes":             `peers: mesh`,
+		"unknown peers":        "peers: hypercube\nnodes: [{count: 2}]",
+		"unknown field":        "nodez: [{count: 2}]",
+		"short adjacency":      "adjacency: [[1]]\nnodes: [{count: 2}]",
+		"self peer":            "adjacency: [[0], [0]]\nnodes: [{count: 2}]",
+		"vaults without smilo": "vaults: 2\nnodes: [{count: 2}]",
+		"unknown faulty mode":  "nodes: [{count: 2}, {faulty: 42}]",
+	}
+	for name, content := range topologies {
+		path := writeTopology(t, "topology.yml", content)
+		if _, err := ReadTopology(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		os.RemoveAll(filepath.Dir(path))
+	}
+}
+
+func TestReadTopologyJSONUnknownField(t *testing.T) {
+	path := writeTopology(t, "topology.json", `{"nodez": [{"count": 2}]}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	if _, err := ReadTopology(path); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
@@ -29,16 +29,16 @@ import (
 	"strings"
 	"time"
 
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/filters"
-	"github.com/docker/docker/api/types/network"
-	"github.com/docker/docker/client"
-	"github.com/docker/go-connections/nat"
+	"github.com/inconshreveable/log15"
 
 	"go-smilo/src/blockchain/regression/src/common"
 )
 
+const (
+	vaultStopTimeout = 10 * time.Second
+	vaultInitTimeout = 15 * time.Second
+)
+
 //TODO: refactor this with ethereum options?
 /**
  * Vault options
@@ -128,35 +128,30 @@ type Vault interface {
 	Binds() []string
 	// PublicKeys() return public keys
 	PublicKeys() []string
+	// Name() returns the name of the vault in log lines
+	Name() string
+	// Logs() writes the logs of the vault's container to stdout and stderr
+	Logs(ctx context.Context, follow bool, stdout, stderr io.Writer) error
+	// CollectArtifacts() saves logs and working directory into dir
+	CollectArtifacts(dir string) error
 }
 
-func NewVault(c *client.Client, options ...VaultOption) *vault {
+func NewVault(rt Runtime, options ...VaultOption) *vault {
 	ct := &vault{
-		client: c,
+		runtime: rt,
 	}
 
 	for _, opt := range options {
 		opt(ct)
 	}
 
-	filters := filters.NewArgs()
-	filters.Add("reference", ct.Image())
-
-	images, err := c.ImageList(context.Background(), types.ImageListOptions{
-		Filters: filters,
-	})
-
-	if len(images) == 0 || err != nil {
-		out, err := ct.client.ImagePull(context.Background(), ct.Image(), types.ImagePullOptions{})
-		if err != nil {
-			log.Error("Failed to pull image", "image", ct.Image(), "err", err)
-			return nil
-		}
-		if ct.logging {
-			io.Copy(os.Stdout, out)
-		} else {
-			io.Copy(ioutil.Discard, out)
-		}
+	var out io.Writer = ioutil.Discard
+	if ct.logging {
+		out = os.Stdout
+	}
+	if err := ct.runtime.EnsureImage(ct.Image(), out); err != nil {
+		ct.logger().Error("Failed to pull image", "image", ct.Image(), "err", err)
+		return nil
 	}
 
 	return ct
@@ -180,7 +175,7 @@ type vault struct {
 	dockerNetworkName string
 
 	logging bool
-	client  *client.Client
+	runtime Runtime
 }
 
 func (ct *vault) Image() string {
@@ -194,12 +189,12 @@ func (ct *vault) GenerateKey() (localWorkDir string, err error) {
 	// Generate empty password file
 	ct.localWorkDir, err = common.GenerateRandomDir()
 	if err != nil {
-		log.Error("Failed to generate working dir", "dir", ct.localWorkDir, "err", err)
+		ct.logger().Error("Failed to generate working dir", "dir", ct.localWorkDir, "err", err)
 		return "", err
 	}
 	err = os.MkdirAll(filepath.Join(ct.localWorkDir, "keys"), 0700)
 	if err != nil {
-		log.Error("", "dir", filepath.Join(ct.localWorkDir, "keys"), "err", err)
+		ct.logger().Error("", "dir", filepath.Join(ct.localWorkDir, "keys"), "err", err)
 		return "", err
 	}
 
@@ -209,75 +204,57 @@ func (ct *vault) GenerateKey() (localWorkDir string, err error) {
 	localConfigPath := ct.localConfigPath()
 	err = ioutil.WriteFile(localConfigPath, []byte(configContent), 0600)
 	if err != nil {
-		log.Error("Failed to write config", "file", localConfigPath, "err", err)
+		ct.logger().Error("Failed to write config", "file", localConfigPath, "err", err)
 		return "", err
 	}
 
 	// Create container and mount working directory
-	binds := ct.Binds()
-	config := &container.Config{
+	id, err := ct.runtime.Create(&Spec{
 		Image: ct.Image(),
 		Cmd: []string{
 			"--generate-keys=" + ct.keyName,
 		},
-		WorkingDir:ct.workDir,
-	}
-	hostConfig := &container.HostConfig{
-		Binds: binds,
-	}
-	resp, err := ct.client.ContainerCreate(context.Background(), config, hostConfig, nil, "")
+		WorkingDir: ct.workDir,
+		Binds:      ct.Binds(),
+	})
 	if err != nil {
-		log.Error("Failed to create container", "err", err)
+		ct.logger().Error("Failed to create container", "err", err)
 		return "", err
 	}
-	id := resp.ID
 
 	// Start container
-	if err := ct.client.ContainerStart(context.Background(), id, types.ContainerStartOptions{}); err != nil {
-		log.Error("Failed to start container", "err", err)
+	if err := ct.runtime.Start(id); err != nil {
+		ct.logger().Error("Failed to start container", "err", err)
 		return "", err
 	}
 
 	// Attach container: for stdin interaction with the container.
 	// - vault-node generatekeys takes stdin as password
-	hiresp, err := ct.client.ContainerAttach(context.Background(), id, types.ContainerAttachOptions{Stream: true, Stdin: true})
-	if err != nil {
-		log.Error("Failed to attach container", "err", err)
+	// - write empty string password to container stdin, ended by a newline
+	//   since the stdin of a process stays open
+	if err := ct.runtime.Attach(id, []byte("\n")); err != nil { //Empty password
+		ct.logger().Error("Failed to attach container", "err", err)
 		return "", err
 	}
-	// - write empty string password to container stdin
-	hiresp.Conn.Write([]byte("")) //Empty password
-
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
-
-	waitC, _ := ct.client.ContainerWait(ctx, id, container.WaitConditionNotRunning)
 
-	if status := <-waitC; status.StatusCode != 0 {
-		//close(finished)
-		err1 := fmt.Errorf("a non-zero code from VAULT ContainerWait: %d", status.StatusCode)
+	if code, err := ct.runtime.Wait(id, vaultInitTimeout); err != nil || code != 0 {
+		err1 := fmt.Errorf("a non-zero code from VAULT ContainerWait: %d, %v", code, err)
 		logCancellationError(err1.Error())
 		return "", err1
 	}
-	log.Info("Managed to start VAULT container ", "id", id)
+	ct.logger().Info("Managed to start VAULT container ", "id", id)
 
-	ctx1, cancel1 := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel1()
-
-	err = ct.client.ContainerKill(ctx1, id, "")
+	err = ct.runtime.Kill(id, "")
 	if err != nil {
-		log.Info("VAULT container finished gracefully.", "err", err)
+		ct.logger().Info("VAULT container finished gracefully.", "err", err)
 	} else {
-		log.Error("VAULT container was killed, something seems wrong.")
+		ct.logger().Error("VAULT container was killed, something seems wrong.")
 		return "", fmt.Errorf("VAULT killed unexpectedly id:%s", id)
 	}
 
-	ctx2, cancel2 := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel2()
-
-	err = ct.client.ContainerRemove(ctx2, id, types.ContainerRemoveOptions{Force: true})
+	err = ct.runtime.Remove(id)
 	if err != nil {
-		log.Error("Failed to remove GETH container", "err", err)
+		ct.logger().Error("Failed to remove GETH container", "err", err)
 	}
 
 	return "", nil
@@ -290,49 +267,27 @@ func (ct *vault) Start() error {
 		}
 	}()
 
-	// container config
-	exposedPorts := make(map[nat.Port]struct{})
-	exposedPorts[nat.Port(ct.port)] = struct{}{}
-	config := &container.Config{
-		Image:        ct.Image(),
-		Cmd:          ct.flags,
-		ExposedPorts: exposedPorts,
-	}
-
-	// host config
-	binds := []string{
-		ct.localWorkDir + ":" + ct.workDir,
-	}
-	hostConfig := &container.HostConfig{
-		Binds: binds,
-	}
-
-	// Setup network config
-	var networkingConfig *network.NetworkingConfig
-	if ct.ip != "" && ct.dockerNetworkName != "" {
-		endpointsConfig := make(map[string]*network.EndpointSettings)
-		endpointsConfig[ct.dockerNetworkName] = &network.EndpointSettings{
-			IPAMConfig: &network.EndpointIPAMConfig{
-				IPv4Address: ct.ip,
-			},
-		}
-		networkingConfig = &network.NetworkingConfig{
-			EndpointsConfig: endpointsConfig,
-		}
-	}
-
 	// Create container
-	resp, err := ct.client.ContainerCreate(context.Background(), config, hostConfig, networkingConfig, "")
+	id, err := ct.runtime.Create(&Spec{
+		Image: ct.Image(),
+		Cmd:   ct.flags,
+		Ports: []PortBinding{
+			{ContainerPort: atoi(ct.port)},
+		},
+		Binds:       ct.Binds(),
+		NetworkName: ct.dockerNetworkName,
+		IP:          ct.ip,
+	})
 	if err != nil {
-		log.Error("Failed to create container", "err", err)
+		ct.logger().Error("Failed to create container", "err", err)
 		return err
 	}
-	ct.containerID = resp.ID
+	ct.containerID = id
 
 	// Start container
-	err = ct.client.ContainerStart(context.Background(), ct.containerID, types.ContainerStartOptions{})
+	err = ct.runtime.Start(ct.containerID)
 	if err != nil {
-		log.Error("Failed to start container", "ip", ct.ip, "err", err)
+		ct.logger().Error("Failed to start container", "err", err)
 		return err
 	}
 
@@ -340,17 +295,26 @@ func (ct *vault) Start() error {
 }
 
 func (ct *vault) Stop() error {
-	err := ct.client.ContainerStop(context.Background(), ct.containerID, nil)
+	err := ct.runtime.Stop(ct.containerID, vaultStopTimeout)
 	if err != nil {
 		return err
 	}
 
 	defer os.RemoveAll(ct.localWorkDir)
 
-	return ct.client.ContainerRemove(context.Background(), ct.containerID,
-		types.ContainerRemoveOptions{
-			Force: true,
-		})
+	return ct.runtime.Remove(ct.containerID)
+}
+
+func (ct *vault) Name() string {
+	// Vaults running as processes share the loopback IP
+	if ct.ip == loopbackIP {
+		return "vault-" + ct.port
+	}
+	return "vault-" + ct.ip
+}
+
+func (ct *vault) Logs(ctx context.Context, follow bool, stdout, stderr io.Writer) error {
+	return ct.runtime.Logs(ctx, ct.containerID, follow, stdout, stderr)
 }
 
 func (ct *vault) Host() string {
@@ -358,19 +322,13 @@ func (ct *vault) Host() string {
 }
 
 func (ct *vault) Running() bool {
-	containers, err := ct.client.ContainerList(context.Background(), types.ContainerListOptions{})
+	status, err := ct.runtime.Inspect(ct.containerID)
 	if err != nil {
-		log.Error("Failed to list containers", "err", err)
+		ct.logger().Error("Failed to inspect container", "err", err)
 		return false
 	}
 
-	for _, c := range containers {
-		if c.ID == ct.containerID {
-			return true
-		}
-	}
-
-	return false
+	return status.Running
 }
 
 func (ct *vault) WorkDir() string {
@@ -389,7 +347,7 @@ func (ct *vault) PublicKeys() []string {
 	keyPath := ct.localKeyPath("pub")
 	keyBytes, err := ioutil.ReadFile(keyPath)
 	if err != nil {
-		log.Error("Unable to read key file", "file", keyPath, "err", err)
+		ct.logger().Error("Unable to read key file", "file", keyPath, "err", err)
 		return nil
 	}
 	return []string{string(keyBytes)}
@@ -399,15 +357,19 @@ func (ct *vault) PublicKeys() []string {
  * Vault internal functions
  **/
 
-func (ct *vault) showLog(context context.Context) {
-	if readCloser, err := ct.client.ContainerLogs(context, ct.containerID,
-		types.ContainerLogsOptions{ShowStderr: true, Follow: true}); err == nil {
-		defer readCloser.Close()
-		_, err = io.Copy(os.Stdout, readCloser)
-		if err != nil && err != io.EOF {
-			log.Error("Failed to print container log", "err", err)
-			return
-		}
+// logger returns a logger with the node, container and ip fields of the
+// vault.
+func (ct *vault) logger() log15.Logger {
+	return log.New("node", ct.Name(), "container", shortID(ct.containerID), "ip", ct.ip)
+}
+
+func (ct *vault) showLog(ctx context.Context) {
+	stdout := newPrefixWriter(os.Stdout, ct.Name()+" | ")
+	stderr := newPrefixWriter(os.Stdout, ct.Name()+" | ")
+	defer stdout.Flush()
+	defer stderr.Flush()
+	if err := ct.runtime.Logs(ctx, ct.containerID, true, stdout, stderr); err != nil {
+		ct.logger().Error("Failed to print container log", "err", err)
 	}
 }
 
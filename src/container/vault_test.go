@@ -21,20 +21,20 @@ package container
 import (
 	"testing"
 
-	"github.com/docker/docker/client"
 	"github.com/phayes/freeport"
 )
 
 func TestVaultContainer(t *testing.T) {
+	requireDocker(t)
 
-	dockerClient, err := client.NewEnvClient()
+	runtime, err := NewEnvDockerRuntime()
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 
 	dockerNetwork, err := NewDockerNetwork()
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 
 	ips, err := dockerNetwork.GetFreeIPAddrs(1)
@@ -45,7 +45,7 @@ func TestVaultContainer(t *testing.T) {
 
 	port := freeport.GetPort()
 
-	ct := NewVault(dockerClient,
+	ct := NewVault(runtime,
 		CTImageRepository(GetVaultImage()),
 		CTImageTag("return_code"),
 		CTHost(ip, port),
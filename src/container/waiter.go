@@ -0,0 +1,290 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	ethtypes "go-smilo/src/blockchain/smilobft/core/types"
+
+	"go-smilo/src/blockchain/regression/src/client"
+)
+
+const (
+	defaultWaitTimeout = 1 * time.Hour
+	resubscribeDelay   = 1 * time.Second
+	peerCheckDelay     = 1 * time.Second
+)
+
+// WaitError is returned by the waits which did not complete, it reports what
+// the node looked like at the end.
+type WaitError struct {
+	// Err is ErrNoBlock, ErrTimeout or ErrNoSubscription if the wait timed
+	// out, the context error if it was cancelled
+	Err error
+	// Height is the last observed block height
+	Height uint64
+	// PeerCount is the last observed peer count, -1 if unknown
+	PeerCount int
+}
+
+func (e *WaitError) Error() string {
+	return fmt.Sprintf("%v (height %d, peers %d)", e.Err, e.Height, e.PeerCount)
+}
+
+func (e *WaitError) Unwrap() error {
+	return e.Err
+}
+
+// waitContext applies the optional timeout of the WaitFor* methods.
+func waitContext(timeout []time.Duration) (context.Context, context.CancelFunc) {
+	t := defaultWaitTimeout
+	if len(timeout) > 0 {
+		t = timeout[0]
+	}
+	return context.WithTimeout(context.Background(), t)
+}
+
+// waitError builds the error of a wait ended by ctx, a deadline becomes
+// timeoutErr.
+func (eth *ethereum) waitError(ctx context.Context, timeoutErr error, height uint64) error {
+	err := ctx.Err()
+	if err == context.DeadlineExceeded {
+		err = timeoutErr
+	}
+
+	peerCount := -1
+	if cli := eth.NewClient(); cli != nil {
+		pctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if peers, perr := cli.AdminPeers(pctx); perr == nil {
+			peerCount = len(peers)
+		}
+		cancel()
+		cli.Close()
+	}
+	return &WaitError{Err: err, Height: height, PeerCount: peerCount}
+}
+
+// followHeads calls fn with the current head and every new one until fn
+// returns true or an error, or ctx is done. Lost subscriptions are renewed,
+// heads arriving meanwhile are caught up with the current head. It returns
+// the last head seen.
+func (eth *ethereum) followHeads(ctx context.Context, fn func(*ethtypes.Header) (bool, error)) (*ethtypes.Header, error) {
+	var last *ethtypes.Header
+	for {
+		done, err := eth.subscribeHeads(ctx, func(head *ethtypes.Header) (bool, error) {
+			last = head
+			return fn(head)
+		})
+		if done || err != nil {
+			return last, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return last, ctx.Err()
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+func (eth *ethereum) FollowHeads(ctx context.Context, fn func(*ethtypes.Header) (bool, error)) error {
+	_, err := eth.followHeads(ctx, fn)
+	return err
+}
+
+// subscribeHeads follows the heads over a single subscription, it returns
+// false without error once the subscription is lost.
+func (eth *ethereum) subscribeHeads(ctx context.Context, fn func(*ethtypes.Header) (bool, error)) (bool, error) {
+	cli := eth.NewClient()
+	if cli == nil {
+		eth.logger().Debug("Failed to retrieve client, retrying")
+		return false, nil
+	}
+	defer cli.Close()
+
+	heads := make(chan *ethtypes.Header, 16)
+	sub, err := cli.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		eth.logger().Debug("Failed to subscribe new head, retrying", "err", err)
+		return false, nil
+	}
+	defer sub.Unsubscribe()
+
+	// Heads before the subscription are not delivered
+	head, err := cli.HeaderByNumber(ctx, nil)
+	if err != nil {
+		eth.logger().Debug("Failed to get current head, retrying", "err", err)
+		return false, nil
+	}
+	if done, err := fn(head); done || err != nil {
+		return done, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case err := <-sub.Err():
+			eth.logger().Debug("Head subscription lost, retrying", "err", err)
+			return false, nil
+		case head := <-heads:
+			if done, err := fn(head); done || err != nil {
+				return done, err
+			}
+		}
+	}
+}
+
+func headHeight(head *ethtypes.Header) uint64 {
+	if head == nil {
+		return 0
+	}
+	return head.Number.Uint64()
+}
+
+// WaitForBlocksContext waits until num blocks are generated after the
+// current one.
+func (eth *ethereum) WaitForBlocksContext(ctx context.Context, num int) error {
+	var first *big.Int
+	last, err := eth.followHeads(ctx, func(head *ethtypes.Header) (bool, error) {
+		if first == nil {
+			first = new(big.Int).Set(head.Number)
+		}
+		return new(big.Int).Sub(head.Number, first).Int64() >= int64(num), nil
+	})
+	if err == ctx.Err() && err != nil {
+		return eth.waitError(ctx, ErrNoBlock, headHeight(last))
+	}
+	return err
+}
+
+// WaitForBlockHeightContext waits until the block at height num is generated.
+func (eth *ethereum) WaitForBlockHeightContext(ctx context.Context, num int) error {
+	last, err := eth.followHeads(ctx, func(head *ethtypes.Header) (bool, error) {
+		return head.Number.Int64() >= int64(num), nil
+	})
+	if err == ctx.Err() && err != nil {
+		return eth.waitError(ctx, ErrTimeout, headHeight(last))
+	}
+	return err
+}
+
+// WaitForNoBlocksContext fails if more than num blocks are generated before
+// ctx is done. It also fails if the heads of the node could never be
+// followed, a stopped node generates no blocks but proves nothing.
+func (eth *ethereum) WaitForNoBlocksContext(ctx context.Context, num int) error {
+	var first *big.Int
+	_, err := eth.followHeads(ctx, func(head *ethtypes.Header) (bool, error) {
+		if first == nil {
+			first = new(big.Int).Set(head.Number)
+		}
+		if new(big.Int).Sub(head.Number, first).Int64() > int64(num) {
+			return false, errors.New("generated more blocks than expected")
+		}
+		return false, nil
+	})
+	if err == ctx.Err() && first == nil {
+		return eth.waitError(ctx, ErrNoSubscription, 0)
+	}
+	if err == ctx.Err() {
+		return nil
+	}
+	return err
+}
+
+// WaitForBalancesContext waits until all the accounts have a balance, the
+// balances are checked on every new block. The node is dialled again for
+// every block, so a restart of the node does not end the wait.
+func (eth *ethereum) WaitForBalancesContext(ctx context.Context, addrs []common.Address) error {
+	pending := make(map[common.Address]bool, len(addrs))
+	for _, addr := range addrs {
+		pending[addr] = true
+	}
+
+	last, err := eth.followHeads(ctx, func(head *ethtypes.Header) (bool, error) {
+		cli := eth.NewClient()
+		if cli == nil {
+			eth.logger().Debug("Failed to retrieve client, retrying")
+			return false, nil
+		}
+		defer cli.Close()
+
+		for addr := range pending {
+			balance, err := cli.BalanceAt(ctx, addr, nil)
+			if err != nil {
+				eth.logger().Debug("Failed to get balance", "addr", addr, "err", err)
+				return false, nil
+			}
+			if balance.Sign() > 0 {
+				delete(pending, addr)
+			}
+		}
+		return len(pending) == 0, nil
+	})
+	if err == ctx.Err() && err != nil {
+		return eth.waitError(ctx, ErrTimeout, headHeight(last))
+	}
+	return err
+}
+
+// WaitForPeersConnectedContext waits until the node has at least
+// expectedPeercount peers. Peer changes have no subscription, they are
+// polled. Failed dials and calls are retried until ctx is done, the node may
+// still be starting.
+func (eth *ethereum) WaitForPeersConnectedContext(ctx context.Context, expectedPeercount int) error {
+	var cli client.Client
+	defer func() {
+		if cli != nil {
+			cli.Close()
+		}
+	}()
+
+	ticker := time.NewTicker(peerCheckDelay)
+	defer ticker.Stop()
+	var height uint64
+	for {
+		if cli == nil {
+			cli = eth.NewClient()
+		}
+		if cli == nil {
+			eth.logger().Debug("Failed to retrieve client, retrying")
+		} else if infos, err := cli.AdminPeers(ctx); err != nil {
+			if ctx.Err() == nil {
+				eth.logger().Debug("Failed to get peers, retrying", "err", err)
+			}
+			cli.Close()
+			cli = nil
+		} else if len(infos) >= expectedPeercount {
+			return nil
+		} else if n, err := cli.BlockNumber(ctx); err == nil {
+			height = n.Uint64()
+		}
+
+		select {
+		case <-ctx.Done():
+			return eth.waitError(ctx, ErrTimeout, height)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package container
+
+import (
+	"testing"
+	"time"
+)
+
+// mineUntil mines a block every period until done is closed.
+func mineUntil(b *fakeBackend, period time.Duration, done chan struct{}) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			b.Mine(1)
+		}
+	}
+}
+
+func TestWaitForBlockHeight(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 1)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(nil); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	geth := bc.Fullnodes()[0]
+	done := make(chan struct{})
+	defer close(done)
+	go mineUntil(rt.backend(geth.IP()), 50*time.Millisecond, done)
+
+	if err := geth.WaitForBlockHeight(5, 10*time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWaitForBlockHeightTimeout(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 1)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(nil); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	geth := bc.Fullnodes()[0]
+	rt.backend(geth.IP()).Mine(2)
+
+	err := geth.WaitForBlockHeight(5, time.Second)
+	waitErr, ok := err.(*WaitError)
+	if !ok {
+		t.Fatalf("expected a *WaitError, have %v", err)
+	}
+	if waitErr.Err != ErrTimeout || waitErr.Height != 2 || waitErr.PeerCount != 0 {
+		t.Errorf("wait error mismatch: have %+v, want err %v, height 2, peers 0", waitErr, ErrTimeout)
+	}
+}
+
+func TestWaitForBlocksResubscribe(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 1)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(nil); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	geth := bc.Fullnodes()[0]
+	errc := make(chan error, 1)
+	go func() {
+		errc <- geth.WaitForBlocks(3, 20*time.Second)
+	}()
+
+	// Drop the subscription before any block
+	<-time.After(200 * time.Millisecond)
+	if err := rt.Stop(geth.ContainerID(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Start(geth.ContainerID()); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go mineUntil(rt.backend(geth.IP()), 100*time.Millisecond, done)
+
+	if err := <-errc; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWaitForNoBlocks(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 1)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(nil); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	geth := bc.Fullnodes()[0]
+	if err := geth.WaitForNoBlocks(0, time.Second); err != nil {
+		t.Errorf("unexpected error without blocks: %v", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go mineUntil(rt.backend(geth.IP()), 50*time.Millisecond, done)
+
+	if err := geth.WaitForNoBlocks(1, 5*time.Second); err == nil {
+		t.Error("expected an error while blocks are generated")
+	}
+}
+
+func TestWaitForNoBlocksWithoutSubscription(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 1)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(nil); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	geth := bc.Fullnodes()[0]
+	if err := rt.Stop(geth.ContainerID(), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	err := geth.WaitForNoBlocks(0, 2*time.Second)
+	waitErr, ok := err.(*WaitError)
+	if !ok {
+		t.Fatalf("expected a *WaitError, have %v", err)
+	}
+	if waitErr.Err != ErrNoSubscription {
+		t.Errorf("wait error mismatch: have %v, want %v", waitErr.Err, ErrNoSubscription)
+	}
+}
+
+func TestWaitForPeersConnectedRedials(t *testing.T) {
+	bc, rt := newTestBlockchain(t, 1)
+	defer finalizeTestBlockchain(bc)
+
+	if err := bc.Start(nil); err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop(true)
+
+	// The node is down when the wait starts
+	geth := bc.Fullnodes()[0]
+	if err := rt.Stop(geth.ContainerID(), 0); err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan error, 1)
+	go func() {
+		<-time.After(500 * time.Millisecond)
+		started <- rt.Start(geth.ContainerID())
+	}()
+
+	if err := geth.WaitForPeersConnected(0, 10*time.Second); err != nil {
+		t.Error(err)
+	}
+	if err := <-started; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -20,11 +20,9 @@ package genesis
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"math/big"
 	"path/filepath"
-	"time"
 
 	"go-smilo/src/blockchain/smilobft/consensus/sport"
 	"go-smilo/src/blockchain/smilobft/core"
@@ -40,9 +38,29 @@ const (
 	InitDifficulty = 1
 )
 
-func New(options ...Option) *core.Genesis {
-	genesis := &core.Genesis{
-		Timestamp:  uint64(time.Now().Unix()),
+// Genesis is a genesis block being built by options. IsGas and IsGasRefunded
+// override the flags of the Smilo chain config, which follow isSmilo when they
+// are nil.
+type Genesis struct {
+	*core.Genesis
+
+	IsGas         *bool
+	IsGasRefunded *bool
+
+	// err is the first error of an option, it is returned by Validate and
+	// Save.
+	err error
+}
+
+func (genesis *Genesis) fail(err error) {
+	if genesis.err == nil {
+		genesis.err = err
+	}
+}
+
+func New(options ...Option) *Genesis {
+	genesis := &Genesis{Genesis: &core.Genesis{
+		Timestamp:  common.SeedTimestamp,
 		GasLimit:   InitGasLimit,
 		Difficulty: big.NewInt(InitDifficulty),
 		Alloc:      make(core.GenesisAlloc),
@@ -58,7 +76,7 @@ func New(options ...Option) *core.Genesis {
 			},
 		},
 		Mixhash: types.SportDigest,
-	}
+	}}
 
 	for _, opt := range options {
 		opt(genesis)
@@ -69,6 +87,10 @@ func New(options ...Option) *core.Genesis {
 
 func NewFileAt(dir string, isSmilo bool, options ...Option) string {
 	genesis := New(options...)
+	if err := genesis.Validate(); err != nil {
+		log.Error("Invalid genesis", "dir", dir, "err", err)
+		return ""
+	}
 	if err := Save(dir, genesis, isSmilo); err != nil {
 		log.Error("Failed to save genesis", "dir", dir, "err", err)
 		return ""
@@ -82,21 +104,30 @@ func NewFile(isSmilo bool, options ...Option) string {
 	return NewFileAt(dir, isSmilo, options...)
 }
 
-func Save(dataDir string, genesis *core.Genesis, isSmilo bool) error {
+func Save(dataDir string, genesis *Genesis, isSmilo bool) error {
+	if genesis.err != nil {
+		return genesis.err
+	}
 	filePath := filepath.Join(dataDir, FileName)
 
 	var raw []byte
 	var err error
 	if isSmilo {
-		raw, err = json.Marshal(ToSmilo(genesis, true))
+		smilo := ToSmilo(genesis.Genesis, true)
+		if genesis.IsGas != nil {
+			smilo.Config.IsGas = *genesis.IsGas
+		}
+		if genesis.IsGasRefunded != nil {
+			smilo.Config.IsGasRefunded = *genesis.IsGasRefunded
+		}
+		raw, err = json.Marshal(smilo)
 	} else {
-		raw, err = json.Marshal(genesis)
+		raw, err = json.Marshal(genesis.Genesis)
 	}
 
-	fmt.Println("genesis.go, Genesis", string(raw))
-
 	if err != nil {
 		return err
 	}
+	log.Debug("Saving genesis", "file", filePath, "genesis", string(raw))
 	return ioutil.WriteFile(filePath, raw, 0600)
 }
@@ -0,0 +1,190 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package genesis
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"go-smilo/src/blockchain/smilobft/core/types"
+)
+
+func TestOptions(t *testing.T) {
+	addr := common.HexToAddress("0x1a9afb711302c5f83b5902843d1c007a1a137632")
+	contract := common.HexToAddress("0x2a9afb711302c5f83b5902843d1c007a1a137632")
+	funded := common.HexToAddress("0x3a9afb711302c5f83b5902843d1c007a1a137632")
+	storage := map[common.Hash]common.Hash{common.HexToHash("0x01"): common.HexToHash("0x02")}
+
+	g := New(
+		ChainID(42),
+		HomesteadBlock(big.NewInt(0)),
+		ByzantiumBlock(big.NewInt(10)),
+		EIP158Block(nil),
+		Epoch(100),
+		SpeakerPolicy(1),
+		Coinbase(addr),
+		Alloc([]common.Address{addr}, big.NewInt(1000)),
+		AccountNonce(addr, 7),
+		AccountCode(contract, []byte{0x60, 0x00}),
+		AccountStorage(contract, storage),
+		AccountBalance(funded, big.NewInt(5)),
+	)
+
+	if g.Config.ChainID.Uint64() != 42 {
+		t.Errorf("chain id mismatch: have %v, want %v", g.Config.ChainID, 42)
+	}
+	if g.Config.HomesteadBlock.Sign() != 0 || g.Config.ByzantiumBlock.Int64() != 10 || g.Config.EIP158Block != nil {
+		t.Errorf("fork blocks mismatch: have homestead %v, byzantium %v, eip158 %v", g.Config.HomesteadBlock, g.Config.ByzantiumBlock, g.Config.EIP158Block)
+	}
+	if g.Config.Sport.Epoch != 100 || g.Config.Sport.SpeakerPolicy != 1 {
+		t.Errorf("sport config mismatch: have %+v", g.Config.Sport)
+	}
+	if g.Coinbase != addr {
+		t.Errorf("coinbase mismatch: have %v, want %v", g.Coinbase, addr)
+	}
+
+	// The nonce of an allocated account keeps its balance
+	if account := g.Alloc[addr]; account.Nonce != 7 || account.Balance.Int64() != 1000 {
+		t.Errorf("account mismatch: have nonce %d and balance %v, want 7 and 1000", account.Nonce, account.Balance)
+	}
+	account := g.Alloc[contract]
+	if account.Balance == nil || account.Balance.Sign() != 0 {
+		t.Errorf("contract balance mismatch: have %v, want 0", account.Balance)
+	}
+	if len(account.Code) != 2 || account.Storage[common.HexToHash("0x01")] != common.HexToHash("0x02") {
+		t.Errorf("contract mismatch: have code %x and storage %v", account.Code, account.Storage)
+	}
+	// Funding an account keeps the other allocations
+	if len(g.Alloc) != 3 || g.Alloc[funded].Balance.Int64() != 5 {
+		t.Errorf("alloc mismatch: have %d accounts and balance %v, want 3 and 5", len(g.Alloc), g.Alloc[funded].Balance)
+	}
+}
+
+func TestSaveIsGas(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		options       []Option
+		isGas         bool
+		isGasRefunded bool
+	}{
+		{nil, true, true},
+		{[]Option{IsGas(false)}, false, true},
+		{[]Option{IsGas(false), IsGasRefunded(false)}, false, false},
+	}
+	for i, test := range tests {
+		if err := Save(dir, New(test.options...), true); err != nil {
+			t.Fatal(err)
+		}
+		raw, err := ioutil.ReadFile(filepath.Join(dir, FileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var g SmiloGenesis
+		if err := json.Unmarshal(raw, &g); err != nil {
+			t.Fatal(err)
+		}
+		if g.Config.IsGas != test.isGas || g.Config.IsGasRefunded != test.isGasRefunded {
+			t.Errorf("test %d: flags mismatch: have isGas %v and isGasRefunded %v, want %v and %v", i, g.Config.IsGas, g.Config.IsGasRefunded, test.isGas, test.isGasRefunded)
+		}
+	}
+}
+
+func newTestGenesis() *Genesis {
+	fullnodes := []common.Address{
+		common.HexToAddress("0x1a9afb711302c5f83b5902843d1c007a1a137632"),
+		common.HexToAddress("0x2a9afb711302c5f83b5902843d1c007a1a137632"),
+	}
+	g := New(
+		Fullnodes(fullnodes...),
+		Alloc(fullnodes, big.NewInt(1000)),
+		AccountCode(common.HexToAddress("0x03"), []byte{0x60, 0x00}),
+	)
+	g.Number = 5
+	g.GasUsed = 21000
+	return g
+}
+
+func TestRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, isSmilo := range []bool{false, true} {
+		g := newTestGenesis()
+		if err := Save(dir, g, isSmilo); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := Load(filepath.Join(dir, FileName))
+		if err != nil {
+			t.Fatalf("smilo %v: %v", isSmilo, err)
+		}
+		if err := loaded.Validate(); err != nil {
+			t.Errorf("smilo %v: loaded genesis is invalid: %v", isSmilo, err)
+		}
+
+		want, _ := json.Marshal(g.Genesis)
+		have, _ := json.Marshal(loaded.Genesis)
+		if string(have) != string(want) {
+			t.Errorf("smilo %v: genesis mismatch:\nhave %s\nwant %s", isSmilo, have, want)
+		}
+		if isSmilo != (loaded.IsGas != nil && *loaded.IsGas && loaded.IsGasRefunded != nil && *loaded.IsGasRefunded) {
+			t.Errorf("smilo %v: gas flags mismatch: have %v and %v", isSmilo, loaded.IsGas, loaded.IsGasRefunded)
+		}
+
+		fullnodes, err := loaded.Fullnodes()
+		if err != nil || len(fullnodes) != 2 {
+			t.Errorf("smilo %v: fullnodes mismatch: have %v, err %v", isSmilo, fullnodes, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Genesis)
+	}{
+		{"no fullnodes", func(g *Genesis) { g.ExtraData = nil }},
+		{"corrupt extra", func(g *Genesis) { g.ExtraData = append(g.ExtraData[:types.SportExtraVanity], 0xff) }},
+		{"wrong mixhash", func(g *Genesis) { g.Mixhash = common.Hash{} }},
+		{"empty alloc", func(g *Genesis) { g.Alloc = nil }},
+		{"no sport config", func(g *Genesis) { g.Config.Sport = nil }},
+	}
+	for _, test := range tests {
+		g := newTestGenesis()
+		test.modify(g)
+		if err := g.Validate(); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+
+	if err := newTestGenesis().Validate(); err != nil {
+		t.Errorf("valid genesis: %v", err)
+	}
+}
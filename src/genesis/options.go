@@ -19,27 +19,26 @@
 package genesis
 
 import (
+	"bytes"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-
-	"go-smilo/src/blockchain/smilobft/core"
-
-	"bytes"
-
 	"github.com/ethereum/go-ethereum/rlp"
 
+	"go-smilo/src/blockchain/smilobft/core"
 	"go-smilo/src/blockchain/smilobft/core/types"
 )
 
-type Option func(*core.Genesis)
+type Option func(*Genesis)
 
 func Fullnodes(addrs ...common.Address) Option {
-	return func(genesis *core.Genesis) {
+	return func(genesis *Genesis) {
 
 		newVanity, err := hexutil.Decode("0x00")
 		if err != nil {
+			genesis.fail(fmt.Errorf("failed to decode vanity: %v", err))
 			return
 		}
 
@@ -56,6 +55,7 @@ func Fullnodes(addrs ...common.Address) Option {
 
 		payload, err := rlp.EncodeToBytes(&ist)
 		if err != nil {
+			genesis.fail(fmt.Errorf("failed to encode fullnodes: %v", err))
 			return
 		}
 
@@ -66,17 +66,157 @@ func Fullnodes(addrs ...common.Address) Option {
 }
 
 func GasLimit(limit uint64) Option {
-	return func(genesis *core.Genesis) {
+	return func(genesis *Genesis) {
 		genesis.GasLimit = limit
 	}
 }
 
+func Timestamp(timestamp uint64) Option {
+	return func(genesis *Genesis) {
+		genesis.Timestamp = timestamp
+	}
+}
+
 func Alloc(addrs []common.Address, balance *big.Int) Option {
-	return func(genesis *core.Genesis) {
-		alloc := make(map[common.Address]core.GenesisAccount)
+	return func(genesis *Genesis) {
+		alloc := make(core.GenesisAlloc)
 		for _, addr := range addrs {
 			alloc[addr] = core.GenesisAccount{Balance: balance}
 		}
 		genesis.Alloc = alloc
 	}
 }
+
+func ChainID(id uint64) Option {
+	return func(genesis *Genesis) {
+		genesis.Config.ChainID = new(big.Int).SetUint64(id)
+	}
+}
+
+// The fork options take the number of the first block of the fork, nil
+// disables the fork.
+
+func HomesteadBlock(number *big.Int) Option {
+	return func(genesis *Genesis) {
+		genesis.Config.HomesteadBlock = number
+	}
+}
+
+// DAOForkBlock also makes the fullnodes support the DAO fork if it is enabled.
+func DAOForkBlock(number *big.Int) Option {
+	return func(genesis *Genesis) {
+		genesis.Config.DAOForkBlock = number
+		genesis.Config.DAOForkSupport = number != nil
+	}
+}
+
+func EIP150Block(number *big.Int) Option {
+	return func(genesis *Genesis) {
+		genesis.Config.EIP150Block = number
+	}
+}
+
+func EIP155Block(number *big.Int) Option {
+	return func(genesis *Genesis) {
+		genesis.Config.EIP155Block = number
+	}
+}
+
+func EIP158Block(number *big.Int) Option {
+	return func(genesis *Genesis) {
+		genesis.Config.EIP158Block = number
+	}
+}
+
+func ByzantiumBlock(number *big.Int) Option {
+	return func(genesis *Genesis) {
+		genesis.Config.ByzantiumBlock = number
+	}
+}
+
+func ConstantinopleBlock(number *big.Int) Option {
+	return func(genesis *Genesis) {
+		genesis.Config.ConstantinopleBlock = number
+	}
+}
+
+// Epoch is the number of blocks after which the Sport votes are reset.
+func Epoch(epoch uint64) Option {
+	return func(genesis *Genesis) {
+		genesis.Config.Sport.Epoch = epoch
+	}
+}
+
+func SpeakerPolicy(policy uint64) Option {
+	return func(genesis *Genesis) {
+		genesis.Config.Sport.SpeakerPolicy = policy
+	}
+}
+
+// IsGas and IsGasRefunded only apply to Smilo genesis files.
+
+func IsGas(isGas bool) Option {
+	return func(genesis *Genesis) {
+		genesis.IsGas = &isGas
+	}
+}
+
+func IsGasRefunded(isGasRefunded bool) Option {
+	return func(genesis *Genesis) {
+		genesis.IsGasRefunded = &isGasRefunded
+	}
+}
+
+func Coinbase(addr common.Address) Option {
+	return func(genesis *Genesis) {
+		genesis.Coinbase = addr
+	}
+}
+
+// AccountCode deploys the code at the address, the balance of an allocated
+// address is kept.
+func AccountCode(addr common.Address, code []byte) Option {
+	return func(genesis *Genesis) {
+		account := genesis.account(addr)
+		account.Code = code
+		genesis.Alloc[addr] = account
+	}
+}
+
+func AccountStorage(addr common.Address, storage map[common.Hash]common.Hash) Option {
+	return func(genesis *Genesis) {
+		account := genesis.account(addr)
+		account.Storage = storage
+		genesis.Alloc[addr] = account
+	}
+}
+
+// AccountBalance funds the address without replacing the other allocations,
+// unlike Alloc.
+func AccountBalance(addr common.Address, balance *big.Int) Option {
+	return func(genesis *Genesis) {
+		account := genesis.account(addr)
+		account.Balance = balance
+		genesis.Alloc[addr] = account
+	}
+}
+
+func AccountNonce(addr common.Address, nonce uint64) Option {
+	return func(genesis *Genesis) {
+		account := genesis.account(addr)
+		account.Nonce = nonce
+		genesis.Alloc[addr] = account
+	}
+}
+
+// account returns the allocation of the address, a new one has no balance.
+func (genesis *Genesis) account(addr common.Address) core.GenesisAccount {
+	if genesis.Alloc == nil {
+		genesis.Alloc = make(core.GenesisAlloc)
+	}
+	account, ok := genesis.Alloc[addr]
+	if !ok || account.Balance == nil {
+		account.Balance = new(big.Int)
+	}
+	return account
+}
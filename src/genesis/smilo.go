@@ -85,8 +85,34 @@ func ToSmilo(g *core.Genesis, isSmilo bool) *SmiloGenesis {
 		Mixhash:    g.Mixhash,
 		Coinbase:   g.Coinbase,
 		Alloc:      g.Alloc,
-		//Number:     g.Number,
-		//GasUsed:    g.GasUsed,
+		Number:     g.Number,
+		GasUsed:    g.GasUsed,
 		ParentHash: g.ParentHash,
 	}
 }
+
+// FromSmilo converts a smilo genesis back to a standard genesis, the gas flags
+// of its chain config are kept as overrides.
+func FromSmilo(s *SmiloGenesis) *Genesis {
+	genesis := &Genesis{Genesis: &core.Genesis{
+		Nonce:      s.Nonce,
+		Timestamp:  s.Timestamp,
+		ExtraData:  s.ExtraData,
+		GasLimit:   s.GasLimit,
+		Difficulty: s.Difficulty,
+		Mixhash:    s.Mixhash,
+		Coinbase:   s.Coinbase,
+		Alloc:      s.Alloc,
+		Number:     s.Number,
+		GasUsed:    s.GasUsed,
+		ParentHash: s.ParentHash,
+	}}
+	if s.Config != nil {
+		genesis.Config = s.Config.ChainConfig
+		if s.Config.IsSmilo {
+			isGas, isGasRefunded := s.Config.IsGas, s.Config.IsGasRefunded
+			genesis.IsGas, genesis.IsGasRefunded = &isGas, &isGasRefunded
+		}
+	}
+	return genesis
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package genesis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"go-smilo/src/blockchain/smilobft/core/types"
+)
+
+// Load reads a genesis file of the standard or the Smilo form. The gas flags
+// of a Smilo genesis are set as IsGas and IsGasRefunded.
+func Load(path string) (*Genesis, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Smilo form is a superset of the standard one
+	var s SmiloGenesis
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse genesis %s: %v", path, err)
+	}
+	return FromSmilo(&s), nil
+}
+
+// Validate checks the genesis is usable by a Sport blockchain: the fullnodes
+// decode from the extra data, the mixhash is the Sport digest and some
+// accounts are allocated.
+func (genesis *Genesis) Validate() error {
+	if genesis.err != nil {
+		return genesis.err
+	}
+	if genesis.Config == nil || genesis.Config.Sport == nil {
+		return errors.New("missing sport config")
+	}
+	if genesis.Mixhash != types.SportDigest {
+		return fmt.Errorf("mixhash %s is not the sport digest %s", genesis.Mixhash.Hex(), types.SportDigest.Hex())
+	}
+	if len(genesis.Alloc) == 0 {
+		return errors.New("no allocated accounts")
+	}
+
+	fullnodes, err := genesis.Fullnodes()
+	if err != nil {
+		return err
+	}
+	if len(fullnodes) == 0 {
+		return errors.New("no fullnodes")
+	}
+	return nil
+}
+
+// Fullnodes decodes the fullnodes from the SportExtra of the extra data.
+func (genesis *Genesis) Fullnodes() ([]common.Address, error) {
+	extra, err := types.ExtractSportExtra(&types.Header{Extra: genesis.ExtraData})
+	if err != nil {
+		return nil, fmt.Errorf("invalid sport extra: %v", err)
+	}
+	return extra.Fullnodes, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package load
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	smilocommon "go-smilo/src/blockchain/regression/src/common"
+	"go-smilo/src/blockchain/regression/src/genesis"
+)
+
+// Account is an account the generator signs transactions for, its nonce is
+// tracked by the signer of the generator.
+type Account struct {
+	Key     *ecdsa.PrivateKey
+	Address common.Address
+}
+
+// NewAccounts generates num accounts from the seeded keys.
+func NewAccounts(num int) ([]*Account, error) {
+	var accounts []*Account
+	for i := 0; i < num; i++ {
+		key, err := smilocommon.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, &Account{
+			Key:     key,
+			Address: crypto.PubkeyToAddress(key.PublicKey),
+		})
+	}
+	return accounts, nil
+}
+
+// Fund allocates the balance to every account in the genesis.
+func Fund(accounts []*Account, balance *big.Int) genesis.Option {
+	return func(g *genesis.Genesis) {
+		for _, a := range accounts {
+			genesis.AccountBalance(a.Address, balance)(g)
+		}
+	}
+}
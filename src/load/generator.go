@@ -0,0 +1,499 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package load
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"go-smilo/src/blockchain/smilobft/accounts/abi"
+	"go-smilo/src/blockchain/smilobft/core/types"
+
+	"go-smilo/src/blockchain/regression/src/client"
+	smilocommon "go-smilo/src/blockchain/regression/src/common"
+	"go-smilo/src/blockchain/regression/src/container"
+	"go-smilo/src/blockchain/regression/src/contract"
+	"go-smilo/src/blockchain/regression/src/signer"
+)
+
+const (
+	defaultTPS            = 10
+	defaultDuration       = time.Minute
+	defaultChainID        = 2017
+	defaultConfirmTimeout = 2 * time.Minute
+	defaultWorkers        = 16
+
+	transferGas = 21000
+	deployGas   = 300000
+	callGas     = 100000
+
+	receiptCheckDelay = time.Second
+)
+
+// Generator submits transactions at a constant rate from many accounts to
+// many nodes, and follows the chain of the first node to see them confirmed.
+type Generator struct {
+	nodes []container.Ethereum
+	// from are the accounts of the signer transactions are sent from
+	from []common.Address
+
+	tps            int
+	duration       time.Duration
+	weights        map[Kind]int
+	privateFor     []string
+	chainID        *big.Int
+	confirmTimeout time.Duration
+	workers        int
+
+	signer   *signer.Signer
+	storage  abi.ABI
+	code     []byte
+	contract common.Address
+	rand     *rand.Rand
+
+	mutex   sync.Mutex
+	report  *Report
+	pending map[common.Hash]*job
+	// failed are the nonces of the transactions the nodes did not take, by
+	// account, they are used again before new ones
+	failed map[common.Address][]uint64
+}
+
+// job is a transaction to submit, from and nonce are not used by private
+// transactions which the node signs. The random choices are made by the
+// dispatcher.
+type job struct {
+	kind  Kind
+	node  int
+	from  common.Address
+	nonce uint64
+	to    common.Address
+	value int64
+	sent  time.Time
+}
+
+// New returns a generator sending to the nodes from the accounts, which must
+// be funded, see Fund. Without accounts it sends from the accounts of the
+// signer given with the Signer option.
+func New(nodes []container.Ethereum, accounts []*Account, options ...Option) *Generator {
+	g := &Generator{
+		nodes:          nodes,
+		tps:            defaultTPS,
+		duration:       defaultDuration,
+		weights:        make(map[Kind]int),
+		chainID:        big.NewInt(defaultChainID),
+		confirmTimeout: defaultConfirmTimeout,
+		workers:        defaultWorkers,
+		rand:           rand.New(rand.NewSource(smilocommon.DeriveSeed("load"))),
+	}
+	for _, opt := range options {
+		opt(g)
+	}
+	if len(g.weights) == 0 {
+		g.weights[Transfer] = 1
+	}
+	if g.signer == nil {
+		g.signer = signer.New(g.chainID)
+	}
+	for _, a := range accounts {
+		g.from = append(g.from, g.signer.AddKey(a.Key))
+	}
+	if len(accounts) == 0 {
+		g.from = g.signer.Accounts()
+	}
+	return g
+}
+
+// Run submits transactions for the duration and waits until they are
+// confirmed or the confirm timeout passes. Failed transactions are reported,
+// not returned as errors.
+func (g *Generator) Run(ctx context.Context) (*Report, error) {
+	if len(g.nodes) == 0 || len(g.from) == 0 {
+		return nil, errors.New("no nodes or accounts to send from")
+	}
+	if g.tps <= 0 {
+		return nil, fmt.Errorf("invalid rate %d", g.tps)
+	}
+	if g.weights[Private] > 0 && len(g.privateFor) == 0 {
+		return nil, errors.New("private transactions need PrivateFor keys")
+	}
+	if err := g.prepare(); err != nil {
+		return nil, err
+	}
+
+	clients := make([]client.Client, len(g.nodes))
+	for i, v := range g.nodes {
+		clients[i] = v.NewClient()
+		if clients[i] == nil {
+			return nil, fmt.Errorf("failed to retrieve client of %s", v.Name())
+		}
+		defer clients[i].Close()
+	}
+
+	g.report = newReport()
+	g.pending = make(map[common.Hash]*job)
+	g.failed = make(map[common.Address][]uint64)
+
+	// Blocks after the current head are confirmed
+	head, err := clients[0].BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	confirmCtx, cancel := context.WithCancel(ctx)
+	confirmed := make(chan struct{})
+	go func() {
+		defer close(confirmed)
+		if err := g.confirm(confirmCtx, head); err != nil && confirmCtx.Err() == nil {
+			log.Error("Failed to follow the chain", "err", err)
+		}
+	}()
+	// The report is only returned once the chain is not followed anymore
+	stopConfirm := func() {
+		cancel()
+		<-confirmed
+	}
+	defer stopConfirm()
+
+	if g.weights[Call] > 0 {
+		if err := g.deployStorage(ctx, clients[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info("Starting load", "tps", g.tps, "duration", g.duration, "nodes", len(g.nodes), "accounts", len(g.from))
+	jobs := make(chan *job, g.workers)
+	var wg sync.WaitGroup
+	for i := 0; i < g.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				g.submit(ctx, clients[j.node], j)
+			}
+		}()
+	}
+
+	start := time.Now()
+	err = g.dispatch(ctx, clients, jobs)
+	close(jobs)
+	wg.Wait()
+	g.report.Duration = time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+
+	g.waitForPending(ctx)
+	stopConfirm()
+
+	log.Info("Load finished", "submitted", g.report.Total().Submitted, "confirmed", g.report.Total().Confirmed, "tps", g.report.TPS())
+	return g.report, nil
+}
+
+// prepare builds the storage contract code and its ABI.
+func (g *Generator) prepare() error {
+	storage, err := abi.JSON(strings.NewReader(contract.SimplestorageABI))
+	if err != nil {
+		return err
+	}
+	args, err := storage.Pack("", big.NewInt(0))
+	if err != nil {
+		return err
+	}
+	g.storage = storage
+	g.code = append(common.FromHex(contract.SimplestorageBin), args...)
+	return nil
+}
+
+// dispatch hands out a job at every tick of the rate until the duration is
+// over. Nonces are taken here so that the jobs of an account are in order.
+func (g *Generator) dispatch(ctx context.Context, clients []client.Client, jobs chan<- *job) error {
+	ticker := time.NewTicker(time.Second / time.Duration(g.tps))
+	defer ticker.Stop()
+	deadline := time.After(g.duration)
+
+	for i := 0; ; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return nil
+		case <-ticker.C:
+		}
+
+		j := &job{
+			kind:  g.pick(),
+			node:  i % len(clients),
+			to:    g.from[g.rand.Intn(len(g.from))],
+			value: g.rand.Int63(),
+		}
+		if j.kind != Private {
+			j.from = g.from[i%len(g.from)]
+			nonce, ok := g.reuseNonce(j.from)
+			if !ok {
+				var err error
+				if nonce, err = g.signer.NextNonce(ctx, clients[j.node], j.from); err != nil {
+					g.fail(j, err)
+					continue
+				}
+			}
+			j.nonce = nonce
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case jobs <- j:
+		}
+	}
+}
+
+// pick returns a kind of transaction by the weights.
+func (g *Generator) pick() Kind {
+	total := 0
+	for _, w := range g.weights {
+		total += w
+	}
+	n := g.rand.Intn(total)
+	for k := Transfer; k <= Private; k++ {
+		if n < g.weights[k] {
+			return k
+		}
+		n -= g.weights[k]
+	}
+	return Transfer
+}
+
+// submit signs and sends the transaction of the job, private transactions
+// are sent from the first account of the node.
+func (g *Generator) submit(ctx context.Context, cli client.Client, j *job) {
+	if j.kind == Private {
+		from := g.nodes[j.node].Accounts()
+		if len(from) == 0 {
+			g.fail(j, fmt.Errorf("%s has no account", g.nodes[j.node].Name()))
+			return
+		}
+		j.sent = time.Now()
+		hash, err := cli.CreatePrivateContract(ctx, from[0], hexutil.Encode(g.code), big.NewInt(deployGas), g.privateFor)
+		if err != nil {
+			g.fail(j, err)
+			return
+		}
+		g.track(common.HexToHash(hash), j)
+		return
+	}
+
+	tx, err := g.sign(j)
+	if err != nil {
+		g.requeue(j)
+		g.fail(j, err)
+		return
+	}
+	// Tracked before sending, so a fast block does not miss it
+	j.sent = time.Now()
+	g.track(tx.Hash(), j)
+	if err := cli.SendRawTransaction(ctx, tx); err != nil {
+		g.untrack(tx.Hash())
+		if !nonceConsumed(err) {
+			g.requeue(j)
+		}
+		g.fail(j, err)
+	}
+}
+
+// nonceConsumed tells whether the node refused a transaction because its
+// nonce is already taken, by a mined or a pending transaction. Such a nonce
+// must not be sent again.
+func nonceConsumed(err error) bool {
+	msg := err.Error()
+	for _, s := range []string{"nonce too low", "already known", "known transaction", "replacement transaction underpriced"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Generator) sign(j *job) (*types.Transaction, error) {
+	var tx *types.Transaction
+	switch j.kind {
+	case Transfer:
+		tx = types.NewTransaction(j.nonce, j.to, big.NewInt(1), transferGas, big.NewInt(0), nil)
+	case Deploy:
+		tx = types.NewContractCreation(j.nonce, big.NewInt(0), deployGas, big.NewInt(0), g.code)
+	case Call:
+		data, err := g.storage.Pack("set", big.NewInt(j.value))
+		if err != nil {
+			return nil, err
+		}
+		tx = types.NewTransaction(j.nonce, g.contract, big.NewInt(0), callGas, big.NewInt(0), data)
+	default:
+		return nil, fmt.Errorf("unknown kind %v", j.kind)
+	}
+	return g.signer.Sign(j.from, tx)
+}
+
+func (g *Generator) track(hash common.Hash, j *job) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.pending[hash] = j
+	g.report.counts(j.kind).Submitted++
+}
+
+func (g *Generator) untrack(hash common.Hash) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if j, ok := g.pending[hash]; ok {
+		delete(g.pending, hash)
+		g.report.counts(j.kind).Submitted--
+	}
+}
+
+// fail records the error of the job.
+func (g *Generator) fail(j *job, err error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.report.counts(j.kind).Failed++
+	g.report.Errors[err.Error()]++
+}
+
+// requeue keeps the nonce of the job the node did not take, the next job of
+// the account sends it. Reading the nonce from the node again instead would
+// race with the transactions of the account still being submitted.
+func (g *Generator) requeue(j *job) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.failed[j.from] = append(g.failed[j.from], j.nonce)
+}
+
+// reuseNonce returns the lowest failed nonce of the account, if any.
+func (g *Generator) reuseNonce(from common.Address) (uint64, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	nonces := g.failed[from]
+	if len(nonces) == 0 {
+		return 0, false
+	}
+	low := 0
+	for i, n := range nonces {
+		if n < nonces[low] {
+			low = i
+		}
+	}
+	nonce := nonces[low]
+	g.failed[from] = append(nonces[:low], nonces[low+1:]...)
+	return nonce, true
+}
+
+// confirm follows the chain of the first node and confirms the pending
+// transactions of every block after last. Heads skipped by the subscription
+// are read one by one.
+func (g *Generator) confirm(ctx context.Context, last *big.Int) error {
+	cli := g.nodes[0].NewClient()
+	if cli == nil {
+		return errors.New("failed to retrieve client")
+	}
+	defer cli.Close()
+
+	return g.nodes[0].FollowHeads(ctx, func(head *types.Header) (bool, error) {
+		for n := new(big.Int).Add(last, common.Big1); n.Cmp(head.Number) <= 0; n.Add(n, common.Big1) {
+			block, err := cli.BlockByNumber(ctx, n)
+			if err != nil {
+				return false, err
+			}
+			g.confirmBlock(block)
+			last = new(big.Int).Set(n)
+		}
+		return false, nil
+	})
+}
+
+func (g *Generator) confirmBlock(block *types.Block) {
+	now := time.Now()
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	for _, tx := range block.Transactions() {
+		j, ok := g.pending[tx.Hash()]
+		if !ok {
+			continue
+		}
+		delete(g.pending, tx.Hash())
+		g.report.counts(j.kind).Confirmed++
+		g.report.Latency.Add(now.Sub(j.sent))
+	}
+}
+
+// waitForPending waits until every submitted transaction is confirmed, or
+// the confirm timeout.
+func (g *Generator) waitForPending(ctx context.Context) {
+	deadline := time.Now().Add(g.confirmTimeout)
+	for time.Now().Before(deadline) {
+		g.mutex.Lock()
+		n := len(g.pending)
+		g.mutex.Unlock()
+		if n == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(receiptCheckDelay):
+		}
+	}
+}
+
+// deployStorage deploys the storage contract the calls set, from the first
+// account.
+func (g *Generator) deployStorage(ctx context.Context, cli client.Client) error {
+	from := g.from[0]
+	tx, err := g.signer.NewTransaction(ctx, cli, from, nil, nil, deployGas, g.code)
+	if err != nil {
+		return err
+	}
+	if err := g.signer.Send(ctx, cli, tx); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(g.confirmTimeout)
+	for {
+		receipt, err := cli.TransactionReceipt(ctx, tx.Hash())
+		if err == nil && receipt != nil {
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				return fmt.Errorf("failed to deploy the storage contract %s", tx.Hash().Hex())
+			}
+			g.contract = crypto.CreateAddress(from, tx.Nonce())
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("storage contract %s is not in a block", tx.Hash().Hex())
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(receiptCheckDelay):
+		}
+	}
+}
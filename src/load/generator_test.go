@@ -0,0 +1,138 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package load
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"go-smilo/src/blockchain/smilobft/core/types"
+
+	"go-smilo/src/blockchain/regression/src/client"
+	"go-smilo/src/blockchain/regression/src/genesis"
+)
+
+func TestPick(t *testing.T) {
+	g := New(nil, nil, Mix(Transfer, 3), Mix(Call, 1))
+	counts := make(map[Kind]int)
+	for i := 0; i < 4000; i++ {
+		counts[g.pick()]++
+	}
+	if counts[Deploy] != 0 || counts[Private] != 0 {
+		t.Errorf("unweighted kinds were picked: %v", counts)
+	}
+	if counts[Transfer] < 2700 || counts[Transfer] > 3300 {
+		t.Errorf("transfers mismatch: have %d of 4000, want about 3000", counts[Transfer])
+	}
+
+	// Only transfers without a mix
+	g = New(nil, nil)
+	for i := 0; i < 100; i++ {
+		if k := g.pick(); k != Transfer {
+			t.Fatalf("kind mismatch: have %v, want %v", k, Transfer)
+		}
+	}
+}
+
+func TestFund(t *testing.T) {
+	accounts, err := NewAccounts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullnode := accounts[0].Address
+	g := genesis.New(
+		genesis.Alloc([]common.Address{fullnode}, big.NewInt(1)),
+		Fund(accounts[1:], big.NewInt(100)),
+	)
+
+	// The allocations of the fullnodes are kept
+	if b := g.Alloc[fullnode].Balance; b.Int64() != 1 {
+		t.Errorf("fullnode balance mismatch: have %v, want 1", b)
+	}
+	for _, a := range accounts[1:] {
+		if b := g.Alloc[a.Address].Balance; b == nil || b.Int64() != 100 {
+			t.Errorf("balance of %s mismatch: have %v, want 100", a.Address.Hex(), b)
+		}
+	}
+}
+
+func TestReuseNonce(t *testing.T) {
+	g := New(nil, nil)
+	g.failed = make(map[common.Address][]uint64)
+	from := common.HexToAddress("0x1")
+
+	g.requeue(&job{from: from, nonce: 7})
+	g.requeue(&job{from: from, nonce: 5})
+	for _, want := range []uint64{5, 7} {
+		nonce, ok := g.reuseNonce(from)
+		if !ok || nonce != want {
+			t.Errorf("nonce mismatch: have %d %v, want %d", nonce, ok, want)
+		}
+	}
+	if _, ok := g.reuseNonce(from); ok {
+		t.Error("nonce reused twice")
+	}
+}
+
+// failingClient fails every transaction with err, the other calls are not
+// used.
+type failingClient struct {
+	client.Client
+	err error
+}
+
+func (c *failingClient) SendRawTransaction(ctx context.Context, tx *types.Transaction) error {
+	return c.err
+}
+
+func TestSubmitFailedSend(t *testing.T) {
+	accounts, err := NewAccounts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := accounts[0].Address
+
+	tests := []struct {
+		err     error
+		requeue bool
+	}{
+		{errors.New("connection refused"), true},
+		{errors.New("nonce too low"), false},
+		{errors.New("known transaction: 0x01"), false},
+	}
+	for _, test := range tests {
+		g := New(nil, accounts)
+		g.report = newReport()
+		g.pending = make(map[common.Hash]*job)
+		g.failed = make(map[common.Address][]uint64)
+
+		g.submit(context.Background(), &failingClient{err: test.err}, &job{kind: Transfer, from: from, nonce: 3, to: from})
+
+		c := g.report.counts(Transfer)
+		if c.Submitted != 0 || c.Failed != 1 || len(g.pending) != 0 {
+			t.Errorf("%v: counts mismatch: have %d submitted, %d failed, %d pending", test.err, c.Submitted, c.Failed, len(g.pending))
+		}
+		nonce, ok := g.reuseNonce(from)
+		if ok != test.requeue || (ok && nonce != 3) {
+			t.Errorf("%v: requeued nonce mismatch: have %d %v, want requeued %v", test.err, nonce, ok, test.requeue)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package load
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Histogram counts durations in buckets whose bounds double from the first
+// one, longer durations fall into the last bucket.
+type Histogram struct {
+	bounds []time.Duration
+	counts []int
+	count  int
+	sum    time.Duration
+	max    time.Duration
+}
+
+// NewHistogram returns a histogram with num buckets of bounds first,
+// 2*first, 4*first and so on, and a bucket for the longer durations.
+func NewHistogram(first time.Duration, num int) *Histogram {
+	h := &Histogram{
+		counts: make([]int, num+1),
+	}
+	for i := 0; i < num; i++ {
+		h.bounds = append(h.bounds, first<<uint(i))
+	}
+	return h
+}
+
+func (h *Histogram) Add(d time.Duration) {
+	i := 0
+	for i < len(h.bounds) && d > h.bounds[i] {
+		i++
+	}
+	h.counts[i]++
+	h.count++
+	h.sum += d
+	if d > h.max {
+		h.max = d
+	}
+}
+
+func (h *Histogram) Count() int {
+	return h.count
+}
+
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
+
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+// Percentile returns the bound of the bucket the p-th percentile falls into,
+// the longest duration for the last bucket.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := int(p / 100 * float64(h.count))
+	if rank >= h.count {
+		rank = h.count - 1
+	}
+	seen := 0
+	for i, c := range h.counts {
+		seen += c
+		if seen > rank {
+			if i < len(h.bounds) {
+				return h.bounds[i]
+			}
+			break
+		}
+	}
+	return h.max
+}
+
+func (h *Histogram) String() string {
+	var buf bytes.Buffer
+	for i, c := range h.counts {
+		if i < len(h.bounds) {
+			fmt.Fprintf(&buf, "<=%v: %d, ", h.bounds[i], c)
+		} else {
+			fmt.Fprintf(&buf, ">%v: %d", h.bounds[len(h.bounds)-1], c)
+		}
+	}
+	return buf.String()
+}
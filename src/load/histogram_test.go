@@ -0,0 +1,59 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package load
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram(100*time.Millisecond, 3)
+	for _, d := range []time.Duration{
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		150 * time.Millisecond,
+		300 * time.Millisecond,
+		time.Second,
+	} {
+		h.Add(d)
+	}
+
+	if h.Count() != 5 || h.Max() != time.Second {
+		t.Errorf("count or max mismatch: have %d and %v", h.Count(), h.Max())
+	}
+	if h.Mean() != 320*time.Millisecond {
+		t.Errorf("mean mismatch: have %v, want %v", h.Mean(), 320*time.Millisecond)
+	}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{50, 200 * time.Millisecond},
+		{70, 400 * time.Millisecond},
+		{99, time.Second},
+	}
+	for _, test := range tests {
+		if have := h.Percentile(test.p); have != test.want {
+			t.Errorf("p%v mismatch: have %v, want %v", test.p, have, test.want)
+		}
+	}
+	if s := h.String(); s != "<=100ms: 2, <=200ms: 1, <=400ms: 1, >400ms: 1" {
+		t.Errorf("string mismatch: have %s", s)
+	}
+}
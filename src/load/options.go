@@ -0,0 +1,87 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package load
+
+import (
+	"math/big"
+	"time"
+
+	"go-smilo/src/blockchain/regression/src/signer"
+)
+
+type Option func(*Generator)
+
+// TPS is the rate transactions are submitted at over all nodes.
+func TPS(tps int) Option {
+	return func(g *Generator) {
+		g.tps = tps
+	}
+}
+
+// Duration is how long transactions are submitted for.
+func Duration(d time.Duration) Option {
+	return func(g *Generator) {
+		g.duration = d
+	}
+}
+
+// Mix sets the weight of the kind of transactions, only value transfers are
+// sent if no weight is set.
+func Mix(kind Kind, weight int) Option {
+	return func(g *Generator) {
+		g.weights[kind] = weight
+	}
+}
+
+// PrivateFor are the public keys of the vaults the private transactions are
+// sent to.
+func PrivateFor(keys ...string) Option {
+	return func(g *Generator) {
+		g.privateFor = keys
+	}
+}
+
+// ChainID is the chain ID of the EIP-155 signatures, 2017 of the default
+// genesis if it is not set. It is not used with the Signer option.
+func ChainID(id uint64) Option {
+	return func(g *Generator) {
+		g.chainID = new(big.Int).SetUint64(id)
+	}
+}
+
+// ConfirmTimeout is how long the generator waits for the submitted
+// transactions to be in a block after the duration.
+func ConfirmTimeout(d time.Duration) Option {
+	return func(g *Generator) {
+		g.confirmTimeout = d
+	}
+}
+
+// Workers is the number of transactions submitted at the same time.
+func Workers(n int) Option {
+	return func(g *Generator) {
+		g.workers = n
+	}
+}
+
+// Signer signs the transactions and tracks the nonces instead of a signer of
+// the generator, see signer.ForBlockchain.
+func Signer(s *signer.Signer) Option {
+	return func(g *Generator) {
+		g.signer = s
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package load
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Kind is a kind of transaction the generator sends.
+type Kind int
+
+const (
+	// Transfer sends a value to another account
+	Transfer Kind = iota
+	// Deploy creates a storage contract
+	Deploy
+	// Call sets the value of the storage contract deployed by the generator
+	Call
+	// Private creates a storage contract private for the PrivateFor keys,
+	// it is sent from the account of the node
+	Private
+)
+
+var kindNames = []string{"transfer", "deploy", "call", "private"}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("kind-%d", int(k))
+	}
+	return kindNames[k]
+}
+
+// Counts are the transactions of a kind.
+type Counts struct {
+	Submitted int
+	Confirmed int
+	// Failed transactions were rejected by the node
+	Failed int
+}
+
+// Report is the result of a run of the generator.
+type Report struct {
+	// Duration is the time transactions were submitted for
+	Duration time.Duration
+	Kinds    map[Kind]*Counts
+	// Errors counts the errors of failed transactions by message
+	Errors map[string]int
+	// Latency is the time from the submission of a transaction until it is
+	// in a block
+	Latency *Histogram
+}
+
+func newReport() *Report {
+	return &Report{
+		Kinds:   make(map[Kind]*Counts),
+		Errors:  make(map[string]int),
+		Latency: NewHistogram(250*time.Millisecond, 8),
+	}
+}
+
+func (r *Report) counts(kind Kind) *Counts {
+	c, ok := r.Kinds[kind]
+	if !ok {
+		c = &Counts{}
+		r.Kinds[kind] = c
+	}
+	return c
+}
+
+// Total sums the counts of all kinds.
+func (r *Report) Total() Counts {
+	var total Counts
+	for _, c := range r.Kinds {
+		total.Submitted += c.Submitted
+		total.Confirmed += c.Confirmed
+		total.Failed += c.Failed
+	}
+	return total
+}
+
+// TPS is the rate of confirmed transactions over the duration.
+func (r *Report) TPS() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Total().Confirmed) / r.Duration.Seconds()
+}
+
+func (r *Report) String() string {
+	var buf bytes.Buffer
+	total := r.Total()
+	fmt.Fprintf(&buf, "%d submitted, %d confirmed, %d failed in %v (%.1f tps)\n", total.Submitted, total.Confirmed, total.Failed, r.Duration, r.TPS())
+
+	var kinds []int
+	for k := range r.Kinds {
+		kinds = append(kinds, int(k))
+	}
+	sort.Ints(kinds)
+	for _, k := range kinds {
+		c := r.Kinds[Kind(k)]
+		fmt.Fprintf(&buf, "  %s: %d submitted, %d confirmed, %d failed\n", Kind(k), c.Submitted, c.Confirmed, c.Failed)
+	}
+	fmt.Fprintf(&buf, "latency: mean %v, p50 %v, p99 %v, max %v\n", r.Latency.Mean(), r.Latency.Percentile(50), r.Latency.Percentile(99), r.Latency.Max())
+	fmt.Fprintf(&buf, "  %v\n", r.Latency)
+	for msg, n := range r.Errors {
+		fmt.Fprintf(&buf, "error: %s (%d)\n", msg, n)
+	}
+	return buf.String()
+}
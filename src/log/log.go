@@ -19,21 +19,104 @@
 package log
 
 import (
+	"fmt"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/inconshreveable/log15"
 )
 
-var defaultLogger = log15.New()
+const (
+	// FormatEnv selects the format of the log lines on stdout: terminal
+	// (default), json or logfmt
+	FormatEnv = "REGRESSION_LOG_FORMAT"
+	// LevelEnv is the most verbose level logged: crit, error, warn, info
+	// or debug (default)
+	LevelEnv = "REGRESSION_LOG_LEVEL"
+	// FileEnv is a file the log lines are also appended to in JSON
+	FileEnv = "REGRESSION_LOG_FILE"
+)
+
+// Config configures the logger of the regression tests.
+type Config struct {
+	Format string
+	Level  string
+	File   string
+}
+
+// ConfigFromEnv returns the configuration set by the environment.
+func ConfigFromEnv() Config {
+	return Config{
+		Format: os.Getenv(FormatEnv),
+		Level:  os.Getenv(LevelEnv),
+		File:   os.Getenv(FileEnv),
+	}
+}
+
+var (
+	defaultLogger = log15.New("spec", log15.Lazy{Fn: Spec})
+
+	specMutex sync.RWMutex
+	spec      string
+)
 
 func init() {
-	defaultLogger.SetHandler(
-		log15.MultiHandler(
-			log15.CallerFileHandler(log15.StreamHandler(
-				os.Stdout, log15.TerminalFormat(),
-			)),
-		),
-	)
+	if err := Setup(ConfigFromEnv()); err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid log configuration:", err)
+		Setup(Config{})
+	}
+}
+
+// Setup replaces the handler of all loggers.
+func Setup(cfg Config) error {
+	var format log15.Format
+	switch strings.ToLower(cfg.Format) {
+	case "", "terminal":
+		format = log15.TerminalFormat()
+	case "json":
+		format = log15.JsonFormat()
+	case "logfmt":
+		format = log15.LogfmtFormat()
+	default:
+		return fmt.Errorf("unknown log format %s", cfg.Format)
+	}
+
+	lvl := log15.LvlDebug
+	if cfg.Level != "" {
+		var err error
+		if lvl, err = log15.LvlFromString(strings.ToLower(cfg.Level)); err != nil {
+			return err
+		}
+	}
+
+	handlers := []log15.Handler{
+		log15.CallerFileHandler(log15.StreamHandler(os.Stdout, format)),
+	}
+	if cfg.File != "" {
+		h, err := log15.FileHandler(cfg.File, log15.JsonFormat())
+		if err != nil {
+			return err
+		}
+		handlers = append(handlers, log15.CallerFileHandler(h))
+	}
+
+	defaultLogger.SetHandler(log15.LvlFilterHandler(lvl, log15.MultiHandler(handlers...)))
+	return nil
+}
+
+// SetSpec sets the spec field of the log lines which follow.
+func SetSpec(name string) {
+	specMutex.Lock()
+	defer specMutex.Unlock()
+	spec = name
+}
+
+// Spec returns the spec being run.
+func Spec() string {
+	specMutex.RLock()
+	defer specMutex.RUnlock()
+	return spec
 }
 
 func New(ctx ...interface{}) log15.Logger {
@@ -0,0 +1,72 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer Setup(ConfigFromEnv())
+	defer SetSpec("")
+
+	path := filepath.Join(dir, "regression.log")
+	if err := Setup(Config{Level: "info", File: path}); err != nil {
+		t.Fatal(err)
+	}
+	SetSpec("SFS-01 spec")
+	logger := New("node", "geth-172.19.0.2")
+	logger.Debug("Filtered")
+	logger.Info("Started")
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("number of log lines mismatch: have %d, want %d", len(lines), 1)
+	}
+	var line map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line["msg"] != "Started" || line["spec"] != "SFS-01 spec" || line["node"] != "geth-172.19.0.2" {
+		t.Errorf("log line mismatch: have %v", line)
+	}
+}
+
+func TestSetupInvalid(t *testing.T) {
+	defer Setup(ConfigFromEnv())
+
+	if err := Setup(Config{Format: "xml"}); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+	if err := Setup(Config{Level: "loud"}); err == nil {
+		t.Errorf("expected an error for an unknown level")
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"go-smilo/src/blockchain/smilobft/accounts"
+	"go-smilo/src/blockchain/smilobft/accounts/keystore"
+	"go-smilo/src/blockchain/smilobft/core/types"
+
+	"go-smilo/src/blockchain/regression/src/client"
+	"go-smilo/src/blockchain/regression/src/container"
+	"go-smilo/src/blockchain/regression/src/genesis"
+)
+
+// Signer signs transactions of test accounts with EIP-155 for a chain and
+// tracks their nonces locally, so transactions can be sent as raw ones to any
+// node. Nodes reject EIP-155 signatures before the EIP155Block of the
+// genesis.
+type Signer struct {
+	chainID *big.Int
+	signer  types.Signer
+
+	mutex    sync.Mutex
+	accounts map[common.Address]*account
+	// order is the order the accounts were added in
+	order []common.Address
+}
+
+type account struct {
+	sign func(tx *types.Transaction) (*types.Transaction, error)
+	// nonce is the nonce of the next transaction, it is read from a node
+	// while the account is not synced
+	nonce  uint64
+	synced bool
+}
+
+func New(chainID *big.Int) *Signer {
+	return &Signer{
+		chainID:  chainID,
+		signer:   types.NewEIP155Signer(chainID),
+		accounts: make(map[common.Address]*account),
+	}
+}
+
+// FromGenesis returns a signer for the chain ID of the genesis file.
+func FromGenesis(path string) (*Signer, error) {
+	g, err := genesis.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if g.Config == nil || g.Config.ChainID == nil {
+		return nil, errors.New("genesis has no chain ID")
+	}
+	return New(g.Config.ChainID), nil
+}
+
+// ForBlockchain returns a signer for the genesis of the blockchain holding
+// the accounts of its keystore.
+func ForBlockchain(bc container.Blockchain) (*Signer, error) {
+	s, err := FromGenesis(bc.GenesisFile())
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range bc.Accounts() {
+		s.AddKeystoreAccount(bc.Keystore(), a, container.AccountPassword)
+	}
+	return s, nil
+}
+
+func (s *Signer) ChainID() *big.Int {
+	return new(big.Int).Set(s.chainID)
+}
+
+// AddKey adds the account of the key, the nonce of a known account is kept.
+func (s *Signer) AddKey(key *ecdsa.PrivateKey) common.Address {
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	s.add(addr, func(tx *types.Transaction) (*types.Transaction, error) {
+		return types.SignTx(tx, s.signer, key)
+	})
+	return addr
+}
+
+// AddKeystoreAccount adds an account of the keystore, it is signed with the
+// passphrase without unlocking it.
+func (s *Signer) AddKeystoreAccount(ks *keystore.KeyStore, a accounts.Account, passphrase string) {
+	s.add(a.Address, func(tx *types.Transaction) (*types.Transaction, error) {
+		return ks.SignTxWithPassphrase(a, passphrase, tx, s.chainID)
+	})
+}
+
+func (s *Signer) add(addr common.Address, sign func(tx *types.Transaction) (*types.Transaction, error)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if a, ok := s.accounts[addr]; ok {
+		a.sign = sign
+		return
+	}
+	s.accounts[addr] = &account{sign: sign}
+	s.order = append(s.order, addr)
+}
+
+// Accounts returns the accounts in the order they were added.
+func (s *Signer) Accounts() []common.Address {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]common.Address{}, s.order...)
+}
+
+func (s *Signer) account(addr common.Address) (*account, error) {
+	a, ok := s.accounts[addr]
+	if !ok {
+		return nil, fmt.Errorf("unknown account %s", addr.Hex())
+	}
+	return a, nil
+}
+
+// Sign signs the transaction as it is, with its own nonce.
+func (s *Signer) Sign(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	s.mutex.Lock()
+	a, err := s.account(from)
+	s.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return a.sign(tx)
+}
+
+// Sender returns the account which signed the transaction.
+func (s *Signer) Sender(tx *types.Transaction) (common.Address, error) {
+	return types.Sender(s.signer, tx)
+}
+
+// NextNonce returns the nonce of the next transaction of the account and
+// counts it as used. The pending nonce is read from the node if the account
+// is not synced.
+func (s *Signer) NextNonce(ctx context.Context, cli client.Client, from common.Address) (uint64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	a, err := s.account(from)
+	if err != nil {
+		return 0, err
+	}
+	if !a.synced {
+		if cli == nil {
+			return 0, fmt.Errorf("nonce of %s is not synced", from.Hex())
+		}
+		nonce, err := cli.PendingNonceAt(ctx, from)
+		if err != nil {
+			return 0, err
+		}
+		a.nonce = nonce
+		a.synced = true
+	}
+	nonce := a.nonce
+	a.nonce++
+	return nonce, nil
+}
+
+// SetNonce sets the nonce of the next transaction of the account, a used
+// one replays it.
+func (s *Signer) SetNonce(from common.Address, nonce uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	a, err := s.account(from)
+	if err != nil {
+		return err
+	}
+	a.nonce = nonce
+	a.synced = true
+	return nil
+}
+
+// Resync reads the nonce of the account from the node again before its next
+// transaction.
+func (s *Signer) Resync(from common.Address) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if a, ok := s.accounts[from]; ok {
+		a.synced = false
+	}
+}
+
+// NewTransaction builds and signs a transaction of the account with its next
+// nonce and no gas price, a nil to creates a contract.
+func (s *Signer) NewTransaction(ctx context.Context, cli client.Client, from common.Address, to *common.Address, value *big.Int, gas uint64, data []byte) (*types.Transaction, error) {
+	nonce, err := s.NextNonce(ctx, cli, from)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	var tx *types.Transaction
+	if to == nil {
+		tx = types.NewContractCreation(nonce, value, gas, new(big.Int), data)
+	} else {
+		tx = types.NewTransaction(nonce, *to, value, gas, new(big.Int), data)
+	}
+	signed, err := s.Sign(from, tx)
+	if err != nil {
+		s.Resync(from)
+		return nil, err
+	}
+	return signed, nil
+}
+
+// Send sends the signed transaction to the node. The nonces are left alone
+// if the node rejects it, a rejected replay is expected, the caller decides
+// whether to Resync.
+func (s *Signer) Send(ctx context.Context, cli client.Client, tx *types.Transaction) error {
+	return cli.SendRawTransaction(ctx, tx)
+}
@@ -0,0 +1,167 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"go-smilo/src/blockchain/smilobft/accounts/keystore"
+	"go-smilo/src/blockchain/smilobft/core/types"
+
+	"go-smilo/src/blockchain/regression/src/client"
+	"go-smilo/src/blockchain/regression/src/genesis"
+)
+
+func TestSign(t *testing.T) {
+	s := New(big.NewInt(42))
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := s.AddKey(key)
+
+	dir, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	a, err := ks.NewAccount("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddKeystoreAccount(ks, a, "password")
+
+	to := common.HexToAddress("0x01")
+	for _, addr := range []common.Address{from, a.Address} {
+		if err := s.SetNonce(addr, 5); err != nil {
+			t.Fatal(err)
+		}
+		tx, err := s.NewTransaction(context.Background(), nil, addr, &to, big.NewInt(1), 21000, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tx.Nonce() != 5 || tx.ChainId().Int64() != 42 || !tx.Protected() {
+			t.Errorf("transaction mismatch: have nonce %d and chain id %v", tx.Nonce(), tx.ChainId())
+		}
+		if sender, err := s.Sender(tx); err != nil || sender != addr {
+			t.Errorf("sender mismatch: have %s (%v), want %s", sender.Hex(), err, addr.Hex())
+		}
+	}
+
+	if have := s.Accounts(); len(have) != 2 || have[0] != from || have[1] != a.Address {
+		t.Errorf("accounts mismatch: have %v", have)
+	}
+	if _, err := s.Sign(common.HexToAddress("0x02"), nil); err == nil {
+		t.Error("signed for an unknown account")
+	}
+}
+
+func TestNonces(t *testing.T) {
+	s := New(big.NewInt(42))
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := s.AddKey(key)
+
+	// An account is synced from a node first
+	if _, err := s.NextNonce(context.Background(), nil, from); err == nil {
+		t.Error("nonce of an unsynced account was given")
+	}
+
+	s.SetNonce(from, 3)
+	for want := uint64(3); want < 6; want++ {
+		if nonce, err := s.NextNonce(context.Background(), nil, from); err != nil || nonce != want {
+			t.Errorf("nonce mismatch: have %d (%v), want %d", nonce, err, want)
+		}
+	}
+
+	// Replaying a nonce and adding the key again
+	s.SetNonce(from, 4)
+	s.AddKey(key)
+	if nonce, _ := s.NextNonce(context.Background(), nil, from); nonce != 4 {
+		t.Errorf("replayed nonce mismatch: have %d, want 4", nonce)
+	}
+
+	s.Resync(from)
+	if _, err := s.NextNonce(context.Background(), nil, from); err == nil {
+		t.Error("nonce of a resynced account was given without a node")
+	}
+}
+
+func TestFromGenesis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fullnode := common.HexToAddress("0x01")
+	path := genesis.NewFileAt(dir, false,
+		genesis.Fullnodes(fullnode),
+		genesis.Alloc([]common.Address{fullnode}, big.NewInt(1)),
+		genesis.ChainID(77),
+	)
+	s, err := FromGenesis(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ChainID().Int64() != 77 {
+		t.Errorf("chain id mismatch: have %v, want 77", s.ChainID())
+	}
+}
+
+// rejectingClient rejects every transaction, the other calls are not used.
+type rejectingClient struct {
+	client.Client
+}
+
+func (c *rejectingClient) SendRawTransaction(ctx context.Context, tx *types.Transaction) error {
+	return errors.New("nonce too low")
+}
+
+func TestSendKeepsNonces(t *testing.T) {
+	s := New(big.NewInt(42))
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := s.AddKey(key)
+	s.SetNonce(from, 5)
+
+	// A rejected replay does not reset the nonces
+	to := common.HexToAddress("0x01")
+	tx, err := s.NewTransaction(context.Background(), nil, from, &to, big.NewInt(1), 21000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(context.Background(), &rejectingClient{}, tx); err == nil {
+		t.Error("expected the error of the node")
+	}
+	if nonce, err := s.NextNonce(context.Background(), nil, from); err != nil || nonce != 6 {
+		t.Errorf("nonce mismatch: have %d (%v), want 6", nonce, err)
+	}
+}
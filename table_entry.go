@@ -19,11 +19,59 @@
 package go_smilo_regression
 
 import (
+	"fmt"
 	"reflect"
 
 	"github.com/onsi/ginkgo"
 )
 
+/*
+DescribeTable describes a table of test cases.  Every entry generates a Ginkgo `Describe` named after the entry,
+in which itBody is called with the parameters of the entry, so itBody declares the `BeforeEach`, `It` and
+`AfterEach` nodes of the case.
+*/
+func DescribeTable(description string, itBody interface{}, entries ...tableEntry) bool {
+	describeTable(description, itBody, entries, false, false)
+	return true
+}
+
+/*
+You can focus a table with `FDescribeTable`.  This is equivalent to `FDescribe`.
+*/
+func FDescribeTable(description string, itBody interface{}, entries ...tableEntry) bool {
+	describeTable(description, itBody, entries, false, true)
+	return true
+}
+
+/*
+You can mark a table as pending with `PDescribeTable`.  This is equivalent to `PDescribe`.
+*/
+func PDescribeTable(description string, itBody interface{}, entries ...tableEntry) bool {
+	describeTable(description, itBody, entries, true, false)
+	return true
+}
+
+func describeTable(description string, itBody interface{}, entries []tableEntry, pending bool, focused bool) {
+	itBodyValue := reflect.ValueOf(itBody)
+	if itBodyValue.Kind() != reflect.Func {
+		panic(fmt.Sprintf("DescribeTable expects a function, got %#v", itBody))
+	}
+
+	body := func() {
+		for _, entry := range entries {
+			entry.generate(itBodyValue, entries, pending, focused)
+		}
+	}
+
+	if pending {
+		ginkgo.PDescribe(description, body)
+	} else if focused {
+		ginkgo.FDescribe(description, body)
+	} else {
+		ginkgo.Describe(description, body)
+	}
+}
+
 /*
 TableEntry represents an entry in a table test.  You generally use the `Entry` constructor.
 */
@@ -34,16 +82,10 @@ type tableEntry struct {
 	Focused     bool
 }
 
-func (t tableEntry) generate(itBody reflect.Value, entries []tableEntry, pending bool, focused bool) {
-	if t.Pending {
-		ginkgo.PDescribe(t.Description, func() {
-			for _, entry := range entries {
-				entry.generate(itBody, entries, pending, focused)
-			}
-		})
-		return
-	}
+// TableEntry lets other packages build tables of entries.
+type TableEntry = tableEntry
 
+func (t tableEntry) generate(itBody reflect.Value, entries []tableEntry, pending bool, focused bool) {
 	values := []reflect.Value{}
 	for i, param := range t.Parameters {
 		var value reflect.Value
@@ -62,7 +104,9 @@ func (t tableEntry) generate(itBody reflect.Value, entries []tableEntry, pending
 		itBody.Call(values)
 	}
 
-	if t.Focused {
+	if t.Pending {
+		ginkgo.PDescribe(t.Description, body)
+	} else if t.Focused {
 		ginkgo.FDescribe(t.Description, body)
 	} else {
 		ginkgo.Describe(t.Description, body)
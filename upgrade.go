@@ -0,0 +1,42 @@
+// Copyright 2020 smilofoundation/regression Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package go_smilo_regression
+
+import (
+	"os"
+)
+
+const (
+	UpgradeFromEnv = "REGRESSION_UPGRADE_FROM"
+	UpgradeToEnv   = "REGRESSION_UPGRADE_TO"
+
+	defaultUpgradeTag = "latest"
+)
+
+// UpgradeTags returns the image tags a rolling upgrade starts from and goes
+// to, given by REGRESSION_UPGRADE_FROM and REGRESSION_UPGRADE_TO. Both default
+// to the latest image.
+func UpgradeTags() (from, to string) {
+	from, to = os.Getenv(UpgradeFromEnv), os.Getenv(UpgradeToEnv)
+	if from == "" {
+		from = defaultUpgradeTag
+	}
+	if to == "" {
+		to = defaultUpgradeTag
+	}
+	return from, to
+}
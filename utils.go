@@ -19,9 +19,14 @@
 package go_smilo_regression
 
 import (
+	"fmt"
 	"sync"
 
+	"github.com/onsi/ginkgo"
+
+	"go-smilo/src/blockchain/regression/src/common"
 	"go-smilo/src/blockchain/regression/src/container"
+	logging "go-smilo/src/blockchain/regression/src/log"
 )
 
 func WaitFor(geths []container.Ethereum, waitFn func(eth container.Ethereum, wg *sync.WaitGroup)) {
@@ -32,3 +37,24 @@ func WaitFor(geths []container.Ethereum, waitFn func(eth container.Ethereum, wg
 	}
 	wg.Wait()
 }
+
+// LogSpec sets the spec field of the log lines to the current spec. Call it
+// from a top level BeforeEach.
+func LogSpec() {
+	logging.SetSpec(ginkgo.CurrentGinkgoTestDescription().FullTestText)
+}
+
+// ReapRun removes the containers, networks and temp dirs the process leaked,
+// call it from AfterSuite. The other processes of a parallel run may still
+// be using theirs.
+func ReapRun() error {
+	reaper, err := container.NewReaper()
+	if err != nil {
+		return err
+	}
+	report, err := reaper.ReapProcess(common.ProcessID())
+	if report != nil && len(report.Containers)+len(report.Networks) > 0 {
+		fmt.Fprintf(ginkgo.GinkgoWriter, "Reaped leaked containers %v and networks %v\n", report.Containers, report.Networks)
+	}
+	return err
+}